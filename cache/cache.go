@@ -0,0 +1,65 @@
+// Package cache provides the key/value stores used to cache hot read paths
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Cache stores byte values under string keys for a limited time. Implementations must be
+// safe for concurrent use. Counters created by Incr are not evicted before their TTL-less
+// lifetime ends, so they can be used as generation numbers for invalidation.
+type Cache interface {
+	// Get returns the value and true, or false when the key is missing or expired
+	Get(key string) ([]byte, bool, error)
+	Set(key string, value []byte, ttl time.Duration) error
+	Delete(keys ...string) error
+	// Incr atomically increments the counter at key, starting from 0, and returns the new value
+	Incr(key string) (int64, error)
+}
+
+// Config selects and configures a Cache implementation
+type Config struct {
+	Backend string // "memory", "redis" or "none"
+	Size    int    // maximum number of entries of the memory cache
+
+	RedisAddr     string
+	RedisPassword string
+	RedisDB       int
+	Prefix        string // prepended to every Redis key
+}
+
+// New returns the configured cache, or nil when caching is disabled
+func New(cfg Config) (Cache, error) {
+	switch cfg.Backend {
+	case "", "none":
+		return nil, nil
+	case "memory":
+		return NewMemoryCache(cfg.Size), nil
+	case "redis":
+		return NewRedisCache(cfg.RedisAddr, cfg.RedisPassword, cfg.RedisDB, cfg.Prefix), nil
+	}
+	return nil, fmt.Errorf("unknown cache backend %q", cfg.Backend)
+}
+
+// GetJSON decodes a cached JSON value into dest and reports whether it was found
+func GetJSON(c Cache, key string, dest interface{}) (bool, error) {
+	data, ok, err := c.Get(key)
+	if err != nil || !ok {
+		return false, err
+	}
+	if err := json.Unmarshal(data, dest); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetJSON stores value encoded as JSON
+func SetJSON(c Cache, key string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return c.Set(key, data, ttl)
+}
@@ -0,0 +1,328 @@
+package cache
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// redisStandIn is a tiny RESP server that understands the commands RedisCache sends
+type redisStandIn struct {
+	listener net.Listener
+
+	mu       sync.Mutex
+	values   map[string]string
+	ttls     map[string]string
+	commands [][]string
+	conns    []net.Conn
+	dials    int
+}
+
+func newRedisStandIn(t *testing.T) *redisStandIn {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &redisStandIn{listener: listener, values: map[string]string{}, ttls: map[string]string{}}
+	t.Cleanup(func() {
+		listener.Close()
+		s.drop()
+	})
+	go s.serve()
+	return s
+}
+
+func (s *redisStandIn) addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *redisStandIn) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns = append(s.conns, conn)
+		s.dials++
+		s.mu.Unlock()
+		go s.handle(conn)
+	}
+}
+
+// drop closes every open client connection, as a server restart would
+func (s *redisStandIn) drop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+}
+
+func (s *redisStandIn) handle(conn net.Conn) {
+	reader := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		if _, err := io.WriteString(conn, s.reply(args)); err != nil {
+			return
+		}
+	}
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimSuffix(line[1:], "\r\n"))
+	if err != nil || line[0] != '*' {
+		return nil, fmt.Errorf("unexpected request line %q", line)
+	}
+	args := make([]string, count)
+	for i := range args {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSuffix(line[1:], "\r\n"))
+		if err != nil || line[0] != '$' {
+			return nil, fmt.Errorf("unexpected argument line %q", line)
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		args[i] = string(data[:size])
+	}
+	return args, nil
+}
+
+func (s *redisStandIn) reply(args []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commands = append(s.commands, args)
+
+	switch strings.ToUpper(args[0]) {
+	case "AUTH":
+		if args[1] != "secret" {
+			return "-WRONGPASS invalid password\r\n"
+		}
+		return "+OK\r\n"
+	case "SELECT":
+		return "+OK\r\n"
+	case "GET":
+		value, ok := s.values[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+	case "SET":
+		s.values[args[1]] = args[2]
+		delete(s.ttls, args[1])
+		if len(args) == 5 && strings.ToUpper(args[3]) == "PX" {
+			s.ttls[args[1]] = args[4]
+		}
+		return "+OK\r\n"
+	case "DEL":
+		deleted := 0
+		for _, key := range args[1:] {
+			if _, ok := s.values[key]; ok {
+				delete(s.values, key)
+				deleted++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", deleted)
+	case "INCR":
+		n, err := strconv.ParseInt(s.values[args[1]], 10, 64)
+		if _, ok := s.values[args[1]]; ok && err != nil {
+			return "-ERR value is not an integer or out of range\r\n"
+		}
+		s.values[args[1]] = strconv.FormatInt(n+1, 10)
+		return fmt.Sprintf(":%d\r\n", n+1)
+	}
+	return fmt.Sprintf("-ERR unknown command '%s'\r\n", args[0])
+}
+
+func (s *redisStandIn) lastCommand() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.commands[len(s.commands)-1]
+}
+
+func (s *redisStandIn) set(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = value
+}
+
+func (s *redisStandIn) stored(key string) (value, ttl string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.values[key], s.ttls[key]
+}
+
+func (s *redisStandIn) dialCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dials
+}
+
+func TestRedisCacheGet(t *testing.T) {
+	server := newRedisStandIn(t)
+	cache := NewRedisCache(server.addr(), "", 0, "blog:")
+
+	value, found, err := cache.Get("missing")
+	if err != nil || found || value != nil {
+		t.Fatalf("Get(missing) = %q, %v, %v; want miss", value, found, err)
+	}
+
+	server.set("blog:post", "hello\r\nworld")
+	value, found, err = cache.Get("post")
+	if err != nil || !found || string(value) != "hello\r\nworld" {
+		t.Fatalf("Get(post) = %q, %v, %v; want hit", value, found, err)
+	}
+	if got := server.lastCommand(); strings.Join(got, " ") != "GET blog:post" {
+		t.Errorf("sent %q, want GET with the prefixed key", got)
+	}
+}
+
+func TestRedisCacheSet(t *testing.T) {
+	server := newRedisStandIn(t)
+	cache := NewRedisCache(server.addr(), "", 0, "blog:")
+
+	if err := cache.Set("post", []byte("body"), 1500*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if got := server.lastCommand(); strings.Join(got, " ") != "SET blog:post body PX 1500" {
+		t.Errorf("sent %q, want SET with a PX ttl", got)
+	}
+	if value, ttl := server.stored("blog:post"); value != "body" || ttl != "1500" {
+		t.Errorf("stored %q with ttl %q", value, ttl)
+	}
+
+	if err := cache.Set("forever", []byte(""), 0); err != nil {
+		t.Fatal(err)
+	}
+	if got := server.lastCommand(); len(got) != 3 {
+		t.Errorf("sent %q, want SET without a ttl", got)
+	}
+
+	value, found, err := cache.Get("post")
+	if err != nil || !found || string(value) != "body" {
+		t.Errorf("Get(post) = %q, %v, %v after Set", value, found, err)
+	}
+
+	if err := cache.Delete("post", "forever"); err != nil {
+		t.Fatal(err)
+	}
+	if got := server.lastCommand(); strings.Join(got, " ") != "DEL blog:post blog:forever" {
+		t.Errorf("sent %q, want DEL of both prefixed keys", got)
+	}
+	if _, found, _ := cache.Get("post"); found {
+		t.Error("Get(post) found a deleted key")
+	}
+}
+
+func TestRedisCacheIncr(t *testing.T) {
+	server := newRedisStandIn(t)
+	cache := NewRedisCache(server.addr(), "", 0, "")
+
+	for want := int64(1); want <= 3; want++ {
+		got, err := cache.Incr("generation")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("Incr() = %d, want %d", got, want)
+		}
+	}
+}
+
+func TestRedisCacheErrorReply(t *testing.T) {
+	server := newRedisStandIn(t)
+	cache := NewRedisCache(server.addr(), "", 0, "")
+	server.set("title", "not a number")
+
+	_, err := cache.Incr("title")
+	var serverErr redisError
+	if !errors.As(err, &serverErr) || !strings.Contains(err.Error(), "not an integer") {
+		t.Fatalf("Incr() error = %v, want the server's error reply", err)
+	}
+
+	// An error reply leaves the connection in a clean state, so it is reused
+	if _, err := cache.Incr("counter"); err != nil {
+		t.Fatal(err)
+	}
+	if dials := server.dialCount(); dials != 1 {
+		t.Errorf("opened %d connections, want the first one reused", dials)
+	}
+}
+
+func TestRedisCacheAuthAndSelect(t *testing.T) {
+	server := newRedisStandIn(t)
+
+	if _, _, err := NewRedisCache(server.addr(), "wrong", 0, "").Get("key"); err == nil || !strings.Contains(err.Error(), "WRONGPASS") {
+		t.Errorf("Get() with a wrong password error = %v, want WRONGPASS", err)
+	}
+
+	if _, _, err := NewRedisCache(server.addr(), "secret", 2, "").Get("key"); err != nil {
+		t.Fatal(err)
+	}
+	server.mu.Lock()
+	sent := server.commands[len(server.commands)-3:]
+	server.mu.Unlock()
+	want := []string{"AUTH secret", "SELECT 2", "GET key"}
+	for i, command := range sent {
+		if strings.Join(command, " ") != want[i] {
+			t.Errorf("command %d = %q, want %q", i, command, want[i])
+		}
+	}
+}
+
+func TestRedisCacheReconnects(t *testing.T) {
+	server := newRedisStandIn(t)
+	cache := NewRedisCache(server.addr(), "", 0, "")
+
+	if err := cache.Set("key", []byte("value"), 0); err != nil {
+		t.Fatal(err)
+	}
+	server.drop()
+
+	// The pooled connection is dead: the command using it may fail, but the connection is
+	// discarded and the next command dials again
+	if _, _, err := cache.Get("key"); err == nil {
+		t.Log("first command after the drop succeeded")
+	}
+	value, found, err := cache.Get("key")
+	if err != nil || !found || string(value) != "value" {
+		t.Fatalf("Get() after reconnecting = %q, %v, %v", value, found, err)
+	}
+	if dials := server.dialCount(); dials != 2 {
+		t.Errorf("opened %d connections, want one new connection after the drop", dials)
+	}
+}
+
+func TestRedisCacheUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	if _, _, err := NewRedisCache(addr, "", 0, "").Get("key"); err == nil {
+		t.Error("Get() against a closed port succeeded")
+	}
+}
@@ -0,0 +1,37 @@
+package cache
+
+import (
+	"expvar"
+	"sync/atomic"
+)
+
+// statsVars holds the statistics of every named cache, served as "cache" on /debug/vars
+var statsVars = expvar.NewMap("cache")
+
+// Stats counts the hits and misses of one cache user
+type Stats struct {
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// NewStats creates Stats published under name; creating it again replaces the published entry
+func NewStats(name string) *Stats {
+	s := &Stats{}
+	statsVars.Set(name, expvar.Func(func() interface{} {
+		hits, misses := s.hits.Load(), s.misses.Load()
+		ratio := 0.0
+		if hits+misses > 0 {
+			ratio = float64(hits) / float64(hits+misses)
+		}
+		return map[string]interface{}{"hits": hits, "misses": misses, "hit_ratio": ratio}
+	}))
+	return s
+}
+
+func (s *Stats) Hit() {
+	s.hits.Add(1)
+}
+
+func (s *Stats) Miss() {
+	s.misses.Add(1)
+}
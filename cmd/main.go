@@ -18,8 +18,15 @@
 package main
 
 import (
+	"context"
 	"crud_api/config"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"crud_api/routes"
 
@@ -39,15 +46,37 @@ func main() {
 	db := config.ConnectDB()
 	// Create an echo instance
 	e := echo.New()
+	// Client addresses identify visitors when counting views, so forwarded ones must come from a trusted proxy
+	e.IPExtractor = config.IPExtractor()
 	// Middleware for logging and recovery
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
-	e.Use(middleware.CORS())
+	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
+		ExposeHeaders: []string{"ETag"}, // needed by browser clients for If-Match
+	}))
+
+	// Background workers stop when the process is asked to terminate
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var workers sync.WaitGroup
 
 	// Register routes
-	routes.RegisterRoutes(e, db)
+	routes.RegisterRoutes(ctx, e, db, &workers)
 
 	e.GET("/swagger/*", echoSwagger.WrapHandler)
 	// Start the server
-	e.Logger.Fatal(e.Start(":8000"))
+	go func() {
+		if err := e.Start(":8000"); err != nil && err != http.ErrServerClosed {
+			e.Logger.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := e.Shutdown(shutdownCtx); err != nil {
+		e.Logger.Error(err)
+	}
+	// Let workers flush pending data (e.g. buffered post views) before exiting
+	workers.Wait()
 }
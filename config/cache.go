@@ -0,0 +1,24 @@
+package config
+
+import (
+	"crud_api/cache"
+	"log"
+)
+
+// NewCache builds the application cache from CACHE_BACKEND (memory, redis or none). It returns
+// nil when caching is disabled or misconfigured, so the application keeps working uncached.
+func NewCache() cache.Cache {
+	c, err := cache.New(cache.Config{
+		Backend:       GetEnv("CACHE_BACKEND", "memory"),
+		Size:          GetEnvInt("CACHE_SIZE", 10000),
+		RedisAddr:     GetEnv("REDIS_ADDR", "localhost:6379"),
+		RedisPassword: GetEnv("REDIS_PASSWORD", ""),
+		RedisDB:       GetEnvInt("REDIS_DB", 0),
+		Prefix:        GetEnv("REDIS_PREFIX", "blog:"),
+	})
+	if err != nil {
+		log.Printf("WARNING: %v, caching disabled", err)
+		return nil
+	}
+	return c
+}
@@ -26,6 +26,14 @@ func ConnectDB() *gorm.DB {
 		panic("failed to connect to database")
 	}
 
-	db.AutoMigrate(&models.User{}, &models.Post{})
+	db.AutoMigrate(&models.User{}, &models.Series{}, &models.Post{}, &models.PostCollaborator{}, &models.Tag{}, &models.Media{}, &models.MediaVariant{}, &models.Comment{}, &models.Reaction{}, &models.Bookmark{}, &models.PostViewVisitor{}, &models.PostDailyViews{}, &models.RelatedPostList{}, &models.RelatedPost{})
+
+	if err := migratePostSearch(db, SearchLanguage()); err != nil {
+		panic("failed to set up post search: " + err.Error())
+	}
+	// Related posts are found through the posts sharing a tag, which the (post_id, tag_id) key can't serve
+	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_post_tags_tag_id ON post_tags (tag_id)").Error; err != nil {
+		panic("failed to index post tags: " + err.Error())
+	}
 	return db
 }
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetEnv returns the value of the environment variable or the fallback when it is unset
+func GetEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+// GetEnvInt reads an integer environment variable, falling back on missing or invalid values
+func GetEnvInt(key string, fallback int) int {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("WARNING: invalid integer for %s=%q, using default %d", key, value, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+// GetEnvDuration reads a duration (e.g. "15m", "24h") environment variable
+func GetEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("WARNING: invalid duration for %s=%q, using default %s", key, value, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+// GetEnvBool reads a boolean environment variable ("true", "1", "false", "0", ...)
+func GetEnvBool(key string, fallback bool) bool {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("WARNING: invalid boolean for %s=%q, using default %t", key, value, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+// GetEnvList reads a comma separated environment variable, dropping empty entries
+func GetEnvList(key string, fallback []string) []string {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// GetEnvIntList reads a comma separated list of integers, falling back when any entry is invalid
+func GetEnvIntList(key string, fallback []int) []int {
+	items := GetEnvList(key, nil)
+	if items == nil {
+		return fallback
+	}
+	values := make([]int, 0, len(items))
+	for _, item := range items {
+		parsed, err := strconv.Atoi(item)
+		if err != nil {
+			log.Printf("WARNING: invalid integer list for %s=%q, using default %v", key, os.Getenv(key), fallback)
+			return fallback
+		}
+		values = append(values, parsed)
+	}
+	return values
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"crud_api/storage"
+	"strings"
+)
+
+// NewStorage builds the storage for uploaded media from MEDIA_STORAGE (local or s3). Unlike the
+// cache, uploads can't work without it, so a misconfiguration stops the application.
+func NewStorage() storage.Storage {
+	backend := GetEnv("MEDIA_STORAGE", "local")
+	// Local files are served by the application itself under the site URL; S3 objects default to the bucket URL
+	publicURL := ""
+	if backend == "local" {
+		publicURL = strings.TrimRight(GetEnv("SITE_URL", "http://localhost:8000"), "/") + "/uploads"
+	}
+
+	store, err := storage.New(storage.Config{
+		Backend:   backend,
+		PublicURL: GetEnv("MEDIA_PUBLIC_URL", publicURL),
+		Dir:       GetEnv("MEDIA_DIR", "./uploads"),
+		S3: storage.S3Config{
+			Endpoint:  GetEnv("S3_ENDPOINT", "https://s3.amazonaws.com"),
+			Region:    GetEnv("S3_REGION", "us-east-1"),
+			Bucket:    GetEnv("S3_BUCKET", ""),
+			AccessKey: GetEnv("S3_ACCESS_KEY", ""),
+			SecretKey: GetEnv("S3_SECRET_KEY", ""),
+			PathStyle: GetEnvBool("S3_PATH_STYLE", false),
+		},
+	})
+	if err != nil {
+		panic("failed to set up media storage: " + err.Error())
+	}
+	return store
+}
@@ -0,0 +1,50 @@
+package config
+
+import "testing"
+
+func TestNewStoragePublicURL(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{
+			name: "local storage under the site URL",
+			env:  map[string]string{"SITE_URL": "https://blog.example.com/"},
+			want: "https://blog.example.com/uploads/media/1/a.png",
+		},
+		{
+			name: "local storage on the default site URL",
+			want: "http://localhost:8000/uploads/media/1/a.png",
+		},
+		{
+			name: "explicit public URL",
+			env:  map[string]string{"SITE_URL": "https://blog.example.com", "MEDIA_PUBLIC_URL": "https://cdn.example.com/files/"},
+			want: "https://cdn.example.com/files/media/1/a.png",
+		},
+		{
+			name: "S3 bucket URL",
+			env: map[string]string{
+				"SITE_URL":      "https://blog.example.com",
+				"MEDIA_STORAGE": "s3",
+				"S3_ENDPOINT":   "https://s3.eu-central-1.amazonaws.com",
+				"S3_BUCKET":     "media",
+				"S3_ACCESS_KEY": "access",
+				"S3_SECRET_KEY": "secret",
+			},
+			want: "https://media.s3.eu-central-1.amazonaws.com/media/1/a.png",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"SITE_URL", "MEDIA_PUBLIC_URL", "MEDIA_STORAGE", "S3_ENDPOINT", "S3_BUCKET", "S3_ACCESS_KEY", "S3_SECRET_KEY", "S3_PATH_STYLE"} {
+				t.Setenv(name, tt.env[name])
+			}
+			t.Setenv("MEDIA_DIR", t.TempDir())
+
+			if got := NewStorage().URL("media/1/a.png"); got != tt.want {
+				t.Errorf("URL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
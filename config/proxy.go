@@ -0,0 +1,37 @@
+package config
+
+import (
+	"log"
+	"net"
+
+	"github.com/labstack/echo/v4"
+)
+
+// IPExtractor decides where client addresses come from. X-Forwarded-For is only believed when
+// the request arrives from one of the TRUSTED_PROXIES (comma separated CIDR ranges or addresses);
+// without any, clients are identified by the address of the connection.
+func IPExtractor() echo.IPExtractor {
+	proxies := GetEnvList("TRUSTED_PROXIES", nil)
+	if len(proxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, proxy := range proxies {
+		_, ipNet, err := net.ParseCIDR(proxy)
+		if err != nil {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				log.Printf("WARNING: ignoring invalid TRUSTED_PROXIES entry %q", proxy)
+				continue
+			}
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			ipNet = &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+		}
+		options = append(options, echo.TrustIPRange(ipNet))
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+
+	"gorm.io/gorm"
+)
+
+var searchLanguagePattern = regexp.MustCompile(`^[a-z_]+$`)
+
+// SearchLanguage is the PostgreSQL text search configuration used to index and query posts
+func SearchLanguage() string {
+	return GetEnv("SEARCH_LANGUAGE", "english")
+}
+
+// migratePostSearch maintains posts.search_vector (title weighted above description) through a
+// trigger, indexes it with GIN and re-indexes existing rows whenever the language changes
+func migratePostSearch(db *gorm.DB, language string) error {
+	if !searchLanguagePattern.MatchString(language) {
+		return fmt.Errorf("invalid SEARCH_LANGUAGE %q", language)
+	}
+
+	var known int64
+	if err := db.Raw("SELECT COUNT(*) FROM pg_ts_config WHERE cfgname = ?", language).Scan(&known).Error; err != nil {
+		return err
+	}
+	if known == 0 {
+		return fmt.Errorf("unknown text search configuration %q", language)
+	}
+
+	statements := []string{
+		`ALTER TABLE posts ADD COLUMN IF NOT EXISTS search_vector tsvector`,
+		`CREATE OR REPLACE FUNCTION posts_search_vector_update() RETURNS trigger AS $$
+BEGIN
+	NEW.search_vector := ` + searchVectorSQL(language, "NEW") + `;
+	RETURN NEW;
+END
+$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS posts_search_vector_trigger ON posts`,
+		`CREATE TRIGGER posts_search_vector_trigger BEFORE INSERT OR UPDATE OF title, description ON posts
+FOR EACH ROW EXECUTE FUNCTION posts_search_vector_update()`,
+		`CREATE INDEX IF NOT EXISTS idx_posts_search_vector ON posts USING GIN (search_vector)`,
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+
+	// The column comment records which language the stored vectors were built with
+	var indexedLanguage *string
+	if err := db.Raw(`SELECT col_description('posts'::regclass, attnum) FROM pg_attribute
+WHERE attrelid = 'posts'::regclass AND attname = 'search_vector'`).Scan(&indexedLanguage).Error; err != nil {
+		return err
+	}
+	if indexedLanguage != nil && *indexedLanguage == language {
+		return nil
+	}
+
+	log.Printf("INFO: re-indexing posts for text search language %q", language)
+	if err := db.Exec("UPDATE posts SET search_vector = " + searchVectorSQL(language, "posts")).Error; err != nil {
+		return err
+	}
+	// Related posts compare search vectors, so lists built with the old language are recomputed
+	if err := db.Exec("UPDATE related_post_lists SET stale_at = now()").Error; err != nil {
+		return err
+	}
+	return db.Exec(fmt.Sprintf("COMMENT ON COLUMN posts.search_vector IS '%s'", language)).Error
+}
+
+// searchVectorSQL builds the weighted vector expression for a row; language is validated by the
+// caller since DDL can't take bind parameters
+func searchVectorSQL(language, row string) string {
+	return fmt.Sprintf(
+		"setweight(to_tsvector('%[1]s', coalesce(%[2]s.title, '')), 'A') || "+
+			"setweight(to_tsvector('%[1]s', coalesce(%[2]s.description, '')), 'B')",
+		language, row,
+	)
+}
@@ -99,6 +99,58 @@ func Forbidden(userMsg string, InternalMsg string, err ...error) *AppErrors {
 	}
 }
 
+func PreconditionFailed(userMsg string, InternalMsg string, err ...error) *AppErrors {
+	var originalErr error
+	if len(err) > 0 {
+		originalErr = err[0]
+	}
+	return &AppErrors{
+		Code:        http.StatusPreconditionFailed,
+		Message:     userMsg,
+		InternalMsg: InternalMsg,
+		Err:         originalErr,
+	}
+}
+
+func PreconditionRequired(userMsg string, InternalMsg string, err ...error) *AppErrors {
+	var originalErr error
+	if len(err) > 0 {
+		originalErr = err[0]
+	}
+	return &AppErrors{
+		Code:        http.StatusPreconditionRequired,
+		Message:     userMsg,
+		InternalMsg: InternalMsg,
+		Err:         originalErr,
+	}
+}
+
+func UnsupportedMediaType(userMsg string, InternalMsg string, err ...error) *AppErrors {
+	var originalErr error
+	if len(err) > 0 {
+		originalErr = err[0]
+	}
+	return &AppErrors{
+		Code:        http.StatusUnsupportedMediaType,
+		Message:     userMsg,
+		InternalMsg: InternalMsg,
+		Err:         originalErr,
+	}
+}
+
+func PayloadTooLarge(userMsg string, InternalMsg string, err ...error) *AppErrors {
+	var originalErr error
+	if len(err) > 0 {
+		originalErr = err[0]
+	}
+	return &AppErrors{
+		Code:        http.StatusRequestEntityTooLarge,
+		Message:     userMsg,
+		InternalMsg: InternalMsg,
+		Err:         originalErr,
+	}
+}
+
 func HandleError(c echo.Context, err error, defaultUserMsg string) error {
 	statusCode := http.StatusInternalServerError
 	userMsg := defaultUserMsg
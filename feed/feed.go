@@ -0,0 +1,45 @@
+// Package feed renders syndication feeds (RSS 2.0, Atom 1.0 and JSON Feed 1.1) from a
+// format independent description
+package feed
+
+import "time"
+
+// Content types of the rendered feeds
+const (
+	RSSType  = "application/rss+xml; charset=utf-8"
+	AtomType = "application/atom+xml; charset=utf-8"
+	JSONType = "application/feed+json; charset=utf-8"
+)
+
+// Feed is a list of entries published by a site. Text fields hold plain text; the renderers
+// take care of escaping.
+type Feed struct {
+	Title       string
+	Description string
+	SiteURL     string // the page the feed belongs to
+	FeedURL     string // where the feed itself is served
+	Updated     time.Time
+	Items       []Item
+}
+
+type Item struct {
+	ID        string // stable and unique; the item URL works well
+	Title     string
+	URL       string
+	Content   string
+	Author    string
+	Tags      []string
+	Published time.Time
+	Updated   time.Time
+}
+
+// LastUpdated returns the most recent update of any item, or the zero time for an empty list
+func LastUpdated(items []Item) time.Time {
+	var latest time.Time
+	for _, item := range items {
+		if item.Updated.After(latest) {
+			latest = item.Updated
+		}
+	}
+	return latest
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"crud_api/errors"
+	"crud_api/models"
+	requestmodels "crud_api/request_models"
+	responsemodels "crud_api/response_models"
+	"crud_api/services"
+
+	"net/http"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/labstack/echo/v4"
+)
+
+type BookmarkHandler struct {
+	service   services.BookmarkService
+	reactions services.ReactionService
+}
+
+// NewBookmarkHandler returns a new instance of BookmarkHandler
+func NewBookmarkHandler(service services.BookmarkService, reactions services.ReactionService) *BookmarkHandler {
+	return &BookmarkHandler{service: service, reactions: reactions}
+}
+
+// AddBookmark godoc
+// @Summary Bookmark a post
+// @Description Save a post to your reading list, optionally in a folder and with a note. Bookmarking an already saved post updates its folder and note. Drafts and archived posts can only be bookmarked by their writers and staff.
+// @Tags bookmarks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param post_id path int true "Post ID"
+// @Param bookmark body requestmodels.BookmarkRequest false "Folder and note"
+// @Success 200 {object} responsemodels.JSONResponseStruct
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/users/me/bookmarks/{post_id} [post]
+func (h *BookmarkHandler) AddBookmark(c echo.Context) error {
+	authUser := c.Get("user").(models.User)
+	postID, err := strconv.Atoi(c.Param("post_id"))
+	if err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid post ID",
+				"Failed to parse post ID as integer",
+				err,
+			),
+			"",
+		)
+	}
+
+	var req requestmodels.BookmarkRequest
+	if err := c.Bind(&req); err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid request body",
+				"Failed to bind request body",
+				err,
+			),
+			"",
+		)
+	}
+
+	req.Sanitize()
+
+	if utf8.RuneCountInString(req.Folder) > 100 || utf8.RuneCountInString(req.Note) > 1000 {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Folder must be at most 100 and note at most 1000 characters",
+				"Client sent oversized bookmark folder or note",
+				nil,
+			),
+			"",
+		)
+	}
+
+	bookmark := requestmodels.FromBookmarkRequest(req, authUser.ID, uint(postID))
+	if err := h.service.Save(&bookmark, authUser); err != nil {
+		return errors.HandleError(c, err, "Failed to save bookmark")
+	}
+
+	return responsemodels.JSONResponse(c, http.StatusOK, "Post bookmarked successfully", nil)
+}
+
+// RemoveBookmark godoc
+// @Summary Remove a bookmark
+// @Description Remove a post from your reading list
+// @Tags bookmarks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param post_id path int true "Post ID"
+// @Success 200 {object} responsemodels.JSONResponseStruct
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/users/me/bookmarks/{post_id} [delete]
+func (h *BookmarkHandler) RemoveBookmark(c echo.Context) error {
+	authUser := c.Get("user").(models.User)
+	postID, err := strconv.Atoi(c.Param("post_id"))
+	if err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid post ID",
+				"Failed to parse post ID as integer",
+				err,
+			),
+			"",
+		)
+	}
+
+	if err := h.service.Remove(authUser.ID, uint(postID)); err != nil {
+		return errors.HandleError(c, err, "Failed to remove bookmark")
+	}
+
+	return responsemodels.JSONResponse(c, http.StatusOK, "Bookmark removed successfully", nil)
+}
+
+// ListBookmarks godoc
+// @Summary List bookmarks
+// @Description Get your reading list, newest first. Bookmarks of deleted posts, and of posts unpublished since, are kept and reported with available=false.
+// @Tags bookmarks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param folder query string false "Only bookmarks in this folder (empty string for unfiled bookmarks)"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Success 200 {object} responsemodels.PaginatedResponse{data=[]responsemodels.BookmarkResponse}
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/users/me/bookmarks [get]
+func (h *BookmarkHandler) ListBookmarks(c echo.Context) error {
+	authUser := c.Get("user").(models.User)
+	p := responsemodels.GetPagination(c)
+
+	var folder *string
+	if c.QueryParams().Has("folder") {
+		value := c.QueryParam("folder")
+		folder = &value
+	}
+
+	bookmarks, total, err := h.service.List(authUser, folder, p.Limit, p.Offset)
+	if err != nil {
+		return errors.HandleError(c, err, "Failed to retrieve bookmarks")
+	}
+
+	if err := h.annotatePosts(bookmarks, authUser.ID); err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	response := []responsemodels.BookmarkResponse{}
+	for _, bookmark := range bookmarks {
+		response = append(response, responsemodels.ToBookmarkResponse(bookmark))
+	}
+
+	paginated := responsemodels.NewPaginatedResponse(response, p.Page, p.Limit, total)
+	return responsemodels.SendPaginatedResponse(c, http.StatusOK, "Bookmarks retrieved successfully", paginated)
+}
+
+// ListBookmarkFolders godoc
+// @Summary List bookmark folders
+// @Description Get the folders of your reading list with the number of bookmarks in each
+// @Tags bookmarks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} responsemodels.JSONResponseStruct{data=[]responsemodels.BookmarkFolderResponse}
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/users/me/bookmarks/folders [get]
+func (h *BookmarkHandler) ListBookmarkFolders(c echo.Context) error {
+	authUser := c.Get("user").(models.User)
+
+	folders, err := h.service.Folders(authUser.ID)
+	if err != nil {
+		return errors.HandleError(c, err, "Failed to retrieve bookmark folders")
+	}
+
+	response := []responsemodels.BookmarkFolderResponse{}
+	for _, folder := range folders {
+		response = append(response, responsemodels.BookmarkFolderResponse{Folder: folder.Folder, Count: folder.Count})
+	}
+
+	return responsemodels.JSONResponse(c, http.StatusOK, "Bookmark folders retrieved successfully", response)
+}
+
+// annotatePosts adds reaction counts to the bookmarked posts that are still available
+func (h *BookmarkHandler) annotatePosts(bookmarks []models.Bookmark, viewerID uint) error {
+	posts := make([]models.Post, 0, len(bookmarks))
+	for _, bookmark := range bookmarks {
+		posts = append(posts, bookmark.Post)
+	}
+	if err := h.reactions.Annotate(posts, viewerID); err != nil {
+		return err
+	}
+	for i := range bookmarks {
+		bookmarks[i].Post = posts[i]
+	}
+	return nil
+}
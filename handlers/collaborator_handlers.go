@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"crud_api/errors"
+	"crud_api/models"
+	requestmodels "crud_api/request_models"
+	responsemodels "crud_api/response_models"
+	"crud_api/services"
+
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type CollaboratorHandler struct {
+	service services.CollaboratorService
+}
+
+// NewCollaboratorHandler returns a new instance of CollaboratorHandler
+func NewCollaboratorHandler(service services.CollaboratorService) *CollaboratorHandler {
+	return &CollaboratorHandler{service: service}
+}
+
+// ListCollaborators godoc
+// @Summary List the collaborators of a post
+// @Description Get the users invited to a post with their role and whether they accepted. Only visible to the post's author, its collaborators and staff.
+// @Tags collaborators
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Post ID"
+// @Success 200 {object} responsemodels.JSONResponseStruct{data=[]responsemodels.CollaboratorResponse}
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 403 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/posts/{id}/collaborators [get]
+func (h *CollaboratorHandler) ListCollaborators(c echo.Context) error {
+	authUser := c.Get("user").(models.User)
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid post ID",
+				"Failed to parse post ID as integer",
+				err,
+			),
+			"",
+		)
+	}
+
+	collaborators, err := h.service.List(uint(postID), authUser)
+	if err != nil {
+		return errors.HandleError(c, err, "Failed to retrieve collaborators")
+	}
+
+	response := []responsemodels.CollaboratorResponse{}
+	for _, collaborator := range collaborators {
+		response = append(response, responsemodels.ToCollaboratorResponse(collaborator))
+	}
+
+	return responsemodels.JSONResponse(c, http.StatusOK, "Collaborators retrieved successfully", response)
+}
+
+// InviteCollaborator godoc
+// @Summary Invite a collaborator
+// @Description Invite a user to a post as owner (may edit and delete the post and manage its collaborators), co-author (may edit it) or reviewer (may read it before it is published). Only owners can invite; the role takes effect once the user accepts.
+// @Tags collaborators
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Post ID"
+// @Param invitation body requestmodels.InviteCollaboratorRequest true "User and role"
+// @Success 201 {object} responsemodels.JSONResponseStruct{data=responsemodels.CollaboratorResponse}
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 403 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 409 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/posts/{id}/collaborators [post]
+func (h *CollaboratorHandler) InviteCollaborator(c echo.Context) error {
+	authUser := c.Get("user").(models.User)
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid post ID",
+				"Failed to parse post ID as integer",
+				err,
+			),
+			"",
+		)
+	}
+
+	var req requestmodels.InviteCollaboratorRequest
+	if err := c.Bind(&req); err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid request body",
+				"Failed to bind request body",
+				err,
+			),
+			"",
+		)
+	}
+
+	if err := requestmodels.Validate(req); err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	collaborator, err := h.service.Invite(uint(postID), authUser, req.UserID, req.Role)
+	if err != nil {
+		return errors.HandleError(c, err, "Failed to invite collaborator")
+	}
+
+	return responsemodels.JSONResponse(c, http.StatusCreated, "Collaborator invited successfully", responsemodels.ToCollaboratorResponse(*collaborator))
+}
+
+// EditCollaborator godoc
+// @Summary Change the role of a collaborator
+// @Description Change the role of a collaborator or invited user (only by the post's owners). The post's author always stays its owner. Send the new role as a JSON Merge Patch or a JSON Patch.
+// @Tags collaborators
+// @Accept json
+// @Accept application/merge-patch+json
+// @Accept application/json-patch+json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Post ID"
+// @Param user_id path int true "User ID"
+// @Param role body requestmodels.CollaboratorRoleRequest true "New role"
+// @Success 200 {object} responsemodels.JSONResponseStruct{data=responsemodels.CollaboratorResponse}
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 403 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 415 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/posts/{id}/collaborators/{user_id} [patch]
+func (h *CollaboratorHandler) EditCollaborator(c echo.Context) error {
+	authUser := c.Get("user").(models.User)
+	postID, userID, err := parseCollaboratorIDs(c)
+	if err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	// Patches apply to the role as it is now, so an empty patch keeps it
+	current := requestmodels.CollaboratorRoleRequest{}
+	collaborators, err := h.service.List(postID, authUser)
+	if err != nil {
+		return errors.HandleError(c, err, "")
+	}
+	for _, collaborator := range collaborators {
+		if collaborator.UserID == userID {
+			current.Role = collaborator.Role
+		}
+	}
+
+	var req requestmodels.CollaboratorRoleRequest
+	if err := applyPatch(c, current, &req); err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	if err := requestmodels.Validate(req); err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	collaborator, err := h.service.ChangeRole(postID, userID, req.Role, authUser)
+	if err != nil {
+		return errors.HandleError(c, err, "Failed to change collaborator role")
+	}
+
+	return responsemodels.JSONResponse(c, http.StatusOK, "Collaborator updated successfully", responsemodels.ToCollaboratorResponse(*collaborator))
+}
+
+// RemoveCollaborator godoc
+// @Summary Remove a collaborator
+// @Description Take a user off a post or withdraw their invitation (only by the post's owners). Collaborators may also remove themselves. The post's author can't be removed.
+// @Tags collaborators
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Post ID"
+// @Param user_id path int true "User ID"
+// @Success 200 {object} responsemodels.JSONResponseStruct
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 403 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/posts/{id}/collaborators/{user_id} [delete]
+func (h *CollaboratorHandler) RemoveCollaborator(c echo.Context) error {
+	authUser := c.Get("user").(models.User)
+	postID, userID, err := parseCollaboratorIDs(c)
+	if err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	if err := h.service.Remove(postID, userID, authUser); err != nil {
+		return errors.HandleError(c, err, "Failed to remove collaborator")
+	}
+
+	return responsemodels.JSONResponse(c, http.StatusOK, "Collaborator removed successfully", nil)
+}
+
+// ListInvitations godoc
+// @Summary List invitations
+// @Description Get the invitations to collaborate on posts that you haven't answered yet, newest first
+// @Tags collaborators
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} responsemodels.JSONResponseStruct{data=[]responsemodels.InvitationResponse}
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/users/me/invitations [get]
+func (h *CollaboratorHandler) ListInvitations(c echo.Context) error {
+	authUser := c.Get("user").(models.User)
+
+	invitations, err := h.service.Invitations(authUser.ID)
+	if err != nil {
+		return errors.HandleError(c, err, "Failed to retrieve invitations")
+	}
+
+	response := []responsemodels.InvitationResponse{}
+	for _, invitation := range invitations {
+		response = append(response, responsemodels.ToInvitationResponse(invitation))
+	}
+
+	return responsemodels.JSONResponse(c, http.StatusOK, "Invitations retrieved successfully", response)
+}
+
+// AcceptInvitation godoc
+// @Summary Accept an invitation
+// @Description Accept your invitation to collaborate on a post; the invited role takes effect immediately
+// @Tags collaborators
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param post_id path int true "Post ID"
+// @Success 200 {object} responsemodels.JSONResponseStruct{data=responsemodels.CollaboratorResponse}
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/users/me/invitations/{post_id}/accept [post]
+func (h *CollaboratorHandler) AcceptInvitation(c echo.Context) error {
+	authUser := c.Get("user").(models.User)
+	postID, err := strconv.Atoi(c.Param("post_id"))
+	if err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid post ID",
+				"Failed to parse post ID as integer",
+				err,
+			),
+			"",
+		)
+	}
+
+	collaborator, err := h.service.Accept(uint(postID), authUser.ID)
+	if err != nil {
+		return errors.HandleError(c, err, "Failed to accept invitation")
+	}
+
+	return responsemodels.JSONResponse(c, http.StatusOK, "Invitation accepted successfully", responsemodels.ToCollaboratorResponse(*collaborator))
+}
+
+// DeclineInvitation godoc
+// @Summary Decline an invitation
+// @Description Decline your invitation to collaborate on a post
+// @Tags collaborators
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param post_id path int true "Post ID"
+// @Success 200 {object} responsemodels.JSONResponseStruct
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/users/me/invitations/{post_id} [delete]
+func (h *CollaboratorHandler) DeclineInvitation(c echo.Context) error {
+	authUser := c.Get("user").(models.User)
+	postID, err := strconv.Atoi(c.Param("post_id"))
+	if err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid post ID",
+				"Failed to parse post ID as integer",
+				err,
+			),
+			"",
+		)
+	}
+
+	if err := h.service.Decline(uint(postID), authUser.ID); err != nil {
+		return errors.HandleError(c, err, "Failed to decline invitation")
+	}
+
+	return responsemodels.JSONResponse(c, http.StatusOK, "Invitation declined successfully", nil)
+}
+
+func parseCollaboratorIDs(c echo.Context) (uint, uint, error) {
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return 0, 0, errors.BadRequest(
+			"Invalid post ID",
+			"Failed to parse post ID as integer",
+			err,
+		)
+	}
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		return 0, 0, errors.BadRequest(
+			"Invalid user ID",
+			"Failed to parse user ID as integer",
+			err,
+		)
+	}
+	return uint(postID), uint(userID), nil
+}
@@ -0,0 +1,422 @@
+package handlers
+
+import (
+	"crud_api/errors"
+	"crud_api/models"
+	"crud_api/repositories"
+	requestmodels "crud_api/request_models"
+	responsemodels "crud_api/response_models"
+	"crud_api/services"
+
+	"net/http"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/labstack/echo/v4"
+)
+
+// maxCommentLength caps the size of a single comment body (in characters)
+const maxCommentLength = 5000
+
+type CommentHandler struct {
+	service services.CommentService
+}
+
+// NewCommentHandler returns a new instance of CommentHandler
+func NewCommentHandler(service services.CommentService) *CommentHandler {
+	return &CommentHandler{service: service}
+}
+
+// ListComments godoc
+// @Summary List comments of a post
+// @Description Get comments of a post either as threads (top-level comments with nested replies) or as a flat chronological list, using cursor pagination. Comments of drafts and archived posts are only listed for their writers and staff.
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param view query string false "Listing mode" Enums(tree, flat) default(tree)
+// @Param after query string false "Cursor returned as next_cursor by the previous page"
+// @Param limit query int false "Items per page (top-level comments in tree view)" default(10)
+// @Success 200 {object} responsemodels.JSONResponseStruct{data=responsemodels.CursorPaginatedResponse{data=[]responsemodels.CommentResponse}}
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/posts/{id}/comments [get]
+func (h *CommentHandler) ListComments(c echo.Context) error {
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid post ID",
+				"Failed to parse post ID as integer",
+				err,
+			),
+			"",
+		)
+	}
+
+	p := responsemodels.GetCursorPagination(c)
+	after, err := repositories.DecodeCursor(p.After)
+	if err != nil {
+		return errors.HandleError(c, err, "")
+	}
+	if after != nil && (after.Positional() || after.Keys != nil) {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid cursor",
+				"Client sent a positional cursor for comments",
+				nil,
+			),
+			"",
+		)
+	}
+
+	// Writers and staff signed in can list the comments of unpublished posts
+	viewer, _ := c.Get("user").(models.User)
+	var comments []models.Comment
+	var next *repositories.Cursor
+	switch c.QueryParam("view") {
+	case "", "tree":
+		comments, next, err = h.service.ListThreads(uint(postID), viewer, after, p.Limit)
+	case "flat":
+		comments, next, err = h.service.ListFlat(uint(postID), viewer, after, p.Limit)
+	default:
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"view must be either 'tree' or 'flat'",
+				"Client sent unknown comment view",
+				nil,
+			),
+			"",
+		)
+	}
+	if err != nil {
+		return errors.HandleError(c, err, "Failed to retrieve comments")
+	}
+
+	response := []responsemodels.CommentResponse{}
+	for _, comment := range comments {
+		response = append(response, responsemodels.ToCommentResponse(comment))
+	}
+
+	nextCursor := ""
+	if next != nil {
+		nextCursor = next.Encode()
+	}
+
+	paginated := responsemodels.NewCursorPaginatedResponse(response, p.Limit, nextCursor)
+	return responsemodels.JSONResponse(c, http.StatusOK, "Comments retrieved successfully", paginated)
+}
+
+// CreateComment godoc
+// @Summary Comment on a post
+// @Description Add a comment to a post, optionally as a reply to another comment (requires authentication). Drafts and archived posts can only be commented on by their writers and staff.
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Post ID"
+// @Param comment body requestmodels.CreateCommentRequest true "Comment content"
+// @Success 201 {object} responsemodels.JSONResponseStruct{data=responsemodels.CommentResponse}
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/posts/{id}/comments [post]
+func (h *CommentHandler) CreateComment(c echo.Context) error {
+	authUser := c.Get("user").(models.User)
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid post ID",
+				"Failed to parse post ID as integer",
+				err,
+			),
+			"",
+		)
+	}
+
+	var req requestmodels.CreateCommentRequest
+	if err := c.Bind(&req); err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid request body",
+				"Failed to bind request body",
+				err,
+			),
+			"",
+		)
+	}
+
+	req.Sanitize()
+
+	if err := validateCommentBody(req.Body); err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	comment := requestmodels.FromCreateCommentRequest(req, uint(postID), authUser.ID)
+	if err := h.service.Create(&comment, authUser); err != nil {
+		return errors.HandleError(c, err, "Failed to create comment")
+	}
+
+	created, err := h.service.GetByID(comment.ID)
+	if err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	return responsemodels.JSONResponse(c, http.StatusCreated, "Comment created successfully", responsemodels.ToCommentResponse(*created))
+}
+
+// EditComment godoc
+// @Summary Edit a comment
+// @Description Edit your own comment while the edit window is still open
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Comment ID"
+// @Param comment body requestmodels.UpdateCommentRequest true "Updated comment content"
+// @Success 200 {object} responsemodels.JSONResponseStruct{data=responsemodels.CommentResponse}
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 403 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/comments/{id} [patch]
+func (h *CommentHandler) EditComment(c echo.Context) error {
+	authUser := c.Get("user").(models.User)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid comment ID",
+				"Failed to parse comment ID as integer",
+				err,
+			),
+			"",
+		)
+	}
+
+	var req requestmodels.UpdateCommentRequest
+	if err := c.Bind(&req); err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid request body",
+				"Failed to bind request body",
+				err,
+			),
+			"",
+		)
+	}
+
+	req.Sanitize()
+
+	if err := validateCommentBody(req.Body); err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	comment, err := h.service.GetByID(uint(id))
+	if err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	requestmodels.FromUpdateCommentRequest(comment, req)
+
+	if err := h.service.Update(comment, authUser.ID); err != nil {
+		return errors.HandleError(c, err, "Failed to update comment")
+	}
+
+	return responsemodels.JSONResponse(c, http.StatusOK, "Comment updated successfully", responsemodels.ToCommentResponse(*comment))
+}
+
+// DeleteComment godoc
+// @Summary Delete a comment
+// @Description Delete your own comment while the edit window is still open. Comments with replies are replaced by a placeholder.
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Comment ID"
+// @Success 200 {object} responsemodels.JSONResponseStruct
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 403 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/comments/{id} [delete]
+func (h *CommentHandler) DeleteComment(c echo.Context) error {
+	authUser := c.Get("user").(models.User)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid comment ID",
+				"Failed to parse comment ID as integer",
+				err,
+			),
+			"",
+		)
+	}
+
+	comment, err := h.service.GetByID(uint(id))
+	if err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	if err := h.service.Delete(comment, authUser.ID); err != nil {
+		return errors.HandleError(c, err, "Failed to delete comment")
+	}
+
+	return responsemodels.JSONResponse(c, http.StatusOK, "Comment deleted successfully", nil)
+}
+
+// ModerationQueue godoc
+// @Summary Comment moderation queue
+// @Description List comments with the given status, oldest first (moderators only)
+// @Tags moderation
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Comment status" Enums(pending, approved, spam, rejected) default(pending)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Success 200 {object} responsemodels.PaginatedResponse{data=[]responsemodels.CommentResponse}
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 403 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/moderation/comments [get]
+func (h *CommentHandler) ModerationQueue(c echo.Context) error {
+	status := c.QueryParam("status")
+	if status == "" {
+		status = models.CommentStatusPending
+	}
+	p := responsemodels.GetPagination(c)
+
+	comments, total, err := h.service.ModerationQueue(status, p.Limit, p.Offset)
+	if err != nil {
+		return errors.HandleError(c, err, "Failed to retrieve moderation queue")
+	}
+
+	response := []responsemodels.CommentResponse{}
+	for _, comment := range comments {
+		response = append(response, responsemodels.ToCommentResponse(comment))
+	}
+
+	paginated := responsemodels.NewPaginatedResponse(response, p.Page, p.Limit, total)
+	return responsemodels.SendPaginatedResponse(c, http.StatusOK, "Moderation queue retrieved successfully", paginated)
+}
+
+// BulkModerateComments godoc
+// @Summary Approve or reject comments in bulk
+// @Description Apply a moderation action to several comments at once (moderators only)
+// @Tags moderation
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body requestmodels.BulkModerateCommentsRequest true "Comment IDs and action (approve, reject or spam)"
+// @Success 200 {object} responsemodels.JSONResponseStruct{data=responsemodels.ModerationResultResponse}
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 403 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/moderation/comments/bulk [post]
+func (h *CommentHandler) BulkModerateComments(c echo.Context) error {
+	var req requestmodels.BulkModerateCommentsRequest
+	if err := c.Bind(&req); err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid request body",
+				"Failed to bind request body",
+				err,
+			),
+			"",
+		)
+	}
+
+	status := req.Status()
+	if status == "" {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"action must be one of approve, reject or spam",
+				"Client sent unknown moderation action",
+				nil,
+			),
+			"",
+		)
+	}
+
+	updated, err := h.service.Moderate(req.IDs, status)
+	if err != nil {
+		return errors.HandleError(c, err, "Failed to moderate comments")
+	}
+
+	return responsemodels.JSONResponse(c, http.StatusOK, "Comments moderated successfully", responsemodels.ModerationResultResponse{Updated: updated})
+}
+
+// LockComments godoc
+// @Summary Lock or unlock comments on a post
+// @Description Prevent (or allow again) new comments on a post. Allowed for the post author and moderators.
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Post ID"
+// @Param request body requestmodels.CommentLockRequest true "Lock state"
+// @Success 200 {object} responsemodels.JSONResponseStruct
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 403 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/posts/{id}/comments/lock [put]
+func (h *CommentHandler) LockComments(c echo.Context) error {
+	authUser := c.Get("user").(models.User)
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid post ID",
+				"Failed to parse post ID as integer",
+				err,
+			),
+			"",
+		)
+	}
+
+	var req requestmodels.CommentLockRequest
+	if err := c.Bind(&req); err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid request body",
+				"Failed to bind request body",
+				err,
+			),
+			"",
+		)
+	}
+
+	if err := h.service.SetCommentsLocked(uint(postID), req.Locked, authUser); err != nil {
+		return errors.HandleError(c, err, "Failed to update comment lock")
+	}
+
+	message := "Comments unlocked successfully"
+	if req.Locked {
+		message = "Comments locked successfully"
+	}
+	return responsemodels.JSONResponse(c, http.StatusOK, message, nil)
+}
+
+func validateCommentBody(body string) error {
+	if body == "" {
+		return errors.BadRequest("Comment body is required", "Client sent empty comment body")
+	}
+	if utf8.RuneCountInString(body) > maxCommentLength {
+		return errors.BadRequest(
+			"Comment body must be at most "+strconv.Itoa(maxCommentLength)+" characters",
+			"Client sent oversized comment body",
+		)
+	}
+	return nil
+}
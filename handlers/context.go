@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"crud_api/models"
+	"crud_api/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// viewerID returns the ID of the signed-in user on routes using the optional JWT
+// middleware, or 0 for anonymous requests
+func viewerID(c echo.Context) uint {
+	if user, ok := c.Get("user").(models.User); ok {
+		return user.ID
+	}
+	return 0
+}
+
+// canSeeUnpublished reports whether the signed-in user may see the author's drafts and
+// archived posts: the author themselves and staff
+func canSeeUnpublished(c echo.Context, authorID uint) bool {
+	user, ok := c.Get("user").(models.User)
+	return ok && (user.ID == authorID || user.IsStaff())
+}
+
+// canSeePost reports whether the signed-in user, if any, may see the post
+func canSeePost(c echo.Context, post *models.Post) bool {
+	user, _ := c.Get("user").(models.User)
+	return services.CanSeePost(post, user)
+}
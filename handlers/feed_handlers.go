@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"crud_api/errors"
+	"crud_api/feed"
+	responsemodels "crud_api/response_models"
+	"crud_api/services"
+
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type FeedHandler struct {
+	service services.FeedService
+}
+
+// NewFeedHandler returns a new instance of FeedHandler
+func NewFeedHandler(service services.FeedService) *FeedHandler {
+	return &FeedHandler{service: service}
+}
+
+// RSS godoc
+// @Summary RSS feed
+// @Description The newest published posts as RSS 2.0, for the whole blog, one category or one author
+// @Tags feeds
+// @Produce application/rss+xml
+// @Param category_id path int false "Category ID (category feed only)"
+// @Param author_id path int false "Author ID (author feed only)"
+// @Param If-None-Match header string false "ETag of a cached copy; answered with 304 while it is current"
+// @Success 200 {string} string "RSS 2.0 document"
+// @Success 304 "Not modified"
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /feeds/rss.xml [get]
+// @Router /feeds/categories/{category_id}/rss.xml [get]
+// @Router /feeds/authors/{author_id}/rss.xml [get]
+func (h *FeedHandler) RSS(c echo.Context) error {
+	return h.serve(c, feed.RSS, feed.RSSType)
+}
+
+// Atom godoc
+// @Summary Atom feed
+// @Description The newest published posts as Atom 1.0, for the whole blog, one category or one author
+// @Tags feeds
+// @Produce application/atom+xml
+// @Param category_id path int false "Category ID (category feed only)"
+// @Param author_id path int false "Author ID (author feed only)"
+// @Param If-None-Match header string false "ETag of a cached copy; answered with 304 while it is current"
+// @Success 200 {string} string "Atom 1.0 document"
+// @Success 304 "Not modified"
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /feeds/atom.xml [get]
+// @Router /feeds/categories/{category_id}/atom.xml [get]
+// @Router /feeds/authors/{author_id}/atom.xml [get]
+func (h *FeedHandler) Atom(c echo.Context) error {
+	return h.serve(c, feed.Atom, feed.AtomType)
+}
+
+// JSONFeed godoc
+// @Summary JSON Feed
+// @Description The newest published posts as JSON Feed 1.1, for the whole blog, one category or one author
+// @Tags feeds
+// @Produce application/feed+json
+// @Param category_id path int false "Category ID (category feed only)"
+// @Param author_id path int false "Author ID (author feed only)"
+// @Param If-None-Match header string false "ETag of a cached copy; answered with 304 while it is current"
+// @Success 200 {string} string "JSON Feed 1.1 document"
+// @Success 304 "Not modified"
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /feeds/feed.json [get]
+// @Router /feeds/categories/{category_id}/feed.json [get]
+// @Router /feeds/authors/{author_id}/feed.json [get]
+func (h *FeedHandler) JSONFeed(c echo.Context) error {
+	return h.serve(c, feed.JSON, feed.JSONType)
+}
+
+// serve renders the feed for the scope in the route parameters. Feeds change whenever a post
+// is published, edited or removed, so like post listings they are only validated by ETag.
+func (h *FeedHandler) serve(c echo.Context, render func(feed.Feed) ([]byte, error), contentType string) error {
+	var scope services.FeedScope
+	for param, target := range map[string]*uint{"category_id": &scope.CategoryID, "author_id": &scope.AuthorID} {
+		value := c.Param(param)
+		if value == "" {
+			continue
+		}
+		id, err := strconv.Atoi(value)
+		if err != nil || id <= 0 {
+			return errors.HandleError(c,
+				errors.BadRequest(
+					"Invalid "+param,
+					"Failed to parse feed "+param+" as integer",
+					err,
+				),
+				"",
+			)
+		}
+		*target = uint(id)
+	}
+
+	// The Host header is up to the client and feeds are cached publicly, so the self link is built from the site URL
+	result, err := h.service.Build(scope, c.Request().URL.Path)
+	if err != nil {
+		return errors.HandleError(c, err, "Failed to build feed")
+	}
+
+	body, err := render(*result)
+	if err != nil {
+		return errors.HandleError(c, errors.Internal("Failed to build feed", "Error rendering feed", err), "")
+	}
+	return responsemodels.ConditionalBlobResponse(c, contentType, body, "", time.Time{})
+}
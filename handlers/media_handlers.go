@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"crud_api/errors"
+	"crud_api/models"
+	responsemodels "crud_api/response_models"
+	"crud_api/services"
+
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type MediaHandler struct {
+	service services.MediaService
+}
+
+// NewMediaHandler returns a new instance of MediaHandler
+func NewMediaHandler(service services.MediaService) *MediaHandler {
+	return &MediaHandler{service: service}
+}
+
+// UploadMedia godoc
+// @Summary Upload a file
+// @Description Upload an image to attach to posts. The file type is detected from its contents, not from the name or the Content-Type sent; only the configured types (by default JPEG, PNG, GIF and WebP) up to the configured size are accepted.
+// @Description Images are stored without their metadata and turned upright. They are returned with status processing while their resized and WebP variants are rendered in the background.
+// @Tags media
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "The file to upload"
+// @Success 201 {object} responsemodels.JSONResponseStruct{data=responsemodels.MediaResponse}
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 413 {object} errors.ErrorResponse
+// @Failure 415 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/media [post]
+func (h *MediaHandler) UploadMedia(c echo.Context) error {
+	authUser := c.Get("user").(models.User)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"A file is required in the 'file' form field",
+				"Failed to read multipart upload",
+				err,
+			),
+			"",
+		)
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return errors.HandleError(c, errors.Internal("Unable to read the uploaded file", "Failed to open multipart file", err), "")
+	}
+	defer file.Close()
+
+	media, err := h.service.Upload(c.Request().Context(), authUser.ID, fileHeader.Filename, fileHeader.Size, file)
+	if err != nil {
+		return errors.HandleError(c, err, "Failed to upload file")
+	}
+
+	return responsemodels.JSONResponse(c, http.StatusCreated, "File uploaded successfully", responsemodels.ToMediaResponse(*media))
+}
+
+// GetMedia godoc
+// @Summary Get an uploaded file
+// @Description Get the details and public URL of an uploaded file, with the URLs of its resized and WebP variants once processing is done
+// @Tags media
+// @Accept json
+// @Produce json
+// @Param id path int true "Media ID"
+// @Success 200 {object} responsemodels.JSONResponseStruct{data=responsemodels.MediaResponse}
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/media/{id} [get]
+func (h *MediaHandler) GetMedia(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid media ID",
+				"Failed to parse media ID as integer",
+				err,
+			),
+			"",
+		)
+	}
+
+	media, err := h.service.GetByID(uint(id))
+	if err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	return responsemodels.JSONResponse(c, http.StatusOK, "Media retrieved successfully", responsemodels.ToMediaResponse(*media))
+}
+
+// ListMyMedia godoc
+// @Summary List own uploads
+// @Description Get the files you uploaded, newest first
+// @Tags media
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Success 200 {object} responsemodels.PaginatedResponse{data=[]responsemodels.MediaResponse}
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/users/me/media [get]
+func (h *MediaHandler) ListMyMedia(c echo.Context) error {
+	authUser := c.Get("user").(models.User)
+	p := responsemodels.GetPagination(c)
+
+	media, total, err := h.service.List(authUser.ID, p.Limit, p.Offset)
+	if err != nil {
+		return errors.HandleError(c, err, "Failed to retrieve media")
+	}
+
+	response := []responsemodels.MediaResponse{}
+	for _, m := range media {
+		response = append(response, responsemodels.ToMediaResponse(m))
+	}
+
+	paginated := responsemodels.NewPaginatedResponse(response, p.Page, p.Limit, total)
+	return responsemodels.SendPaginatedResponse(c, http.StatusOK, "Media retrieved successfully", paginated)
+}
+
+// DeleteMedia godoc
+// @Summary Delete an uploaded file
+// @Description Delete a file (only by its owner or an admin). Posts it was attached to no longer show it.
+// @Tags media
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Media ID"
+// @Success 200 {object} responsemodels.JSONResponseStruct
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 403 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/media/{id} [delete]
+func (h *MediaHandler) DeleteMedia(c echo.Context) error {
+	authUser := c.Get("user").(models.User)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid media ID",
+				"Failed to parse media ID as integer",
+				err,
+			),
+			"",
+		)
+	}
+
+	media, err := h.service.GetByID(uint(id))
+	if err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	if err := h.service.Delete(c.Request().Context(), media, authUser); err != nil {
+		return errors.HandleError(c, err, "Failed to delete media")
+	}
+
+	return responsemodels.JSONResponse(c, http.StatusOK, "Media deleted successfully", nil)
+}
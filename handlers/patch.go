@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"bytes"
+	"crud_api/errors"
+	jsonpatch "crud_api/json_patch"
+	"encoding/json"
+	"io"
+	"mime"
+
+	"github.com/labstack/echo/v4"
+)
+
+// applyPatch applies the request body to current and decodes the result into target. The
+// body is a JSON Merge Patch (application/merge-patch+json, also assumed for plain JSON) or
+// a JSON Patch (application/json-patch+json). Fields target doesn't know are rejected.
+func applyPatch(c echo.Context, current, target interface{}) error {
+	mediaType, _, err := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	if err != nil {
+		mediaType = echo.MIMEApplicationJSON
+	}
+
+	var apply func(doc, patch []byte) ([]byte, error)
+	switch mediaType {
+	case jsonpatch.MergePatchType, echo.MIMEApplicationJSON:
+		apply = jsonpatch.MergePatch
+	case jsonpatch.JSONPatchType:
+		apply = jsonpatch.Apply
+	default:
+		return errors.UnsupportedMediaType(
+			"Send the changes as application/merge-patch+json or application/json-patch+json",
+			"Client sent patch as "+mediaType,
+		)
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return errors.BadRequest("Invalid request body", "Failed to read request body", err)
+	}
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return errors.Internal("Unable to apply changes", "Failed to encode patch target", err)
+	}
+
+	patched, err := apply(doc, body)
+	if err != nil {
+		return errors.BadRequest("Invalid patch: "+err.Error(), "Failed to apply "+mediaType+" patch", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(target); err != nil {
+		return errors.BadRequest("Invalid patch result: "+err.Error(), "Patched document does not decode", err)
+	}
+	return nil
+}
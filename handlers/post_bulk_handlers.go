@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"crud_api/errors"
+	"crud_api/models"
+	"crud_api/repositories"
+	requestmodels "crud_api/request_models"
+	responsemodels "crud_api/response_models"
+	"crud_api/services"
+
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type PostBulkHandler struct {
+	service services.PostBulkService
+}
+
+// NewPostBulkHandler returns a new instance of PostBulkHandler
+func NewPostBulkHandler(service services.PostBulkService) *PostBulkHandler {
+	return &PostBulkHandler{service: service}
+}
+
+// BulkPosts godoc
+// @Summary Change many posts at once
+// @Description Delete, move to another category, change the status of, or add tags to posts selected by ids or by filter.
+// @Description Every post is checked like a single edit or delete would be; posts that can't be changed are reported and skipped.
+// @Description A filter only selects posts you may change: those you own or co-author, or only those you own for delete.
+// @Description All changes are applied in one transaction, so an unexpected error leaves every post untouched.
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body requestmodels.BulkPostRequest true "Action and posts"
+// @Success 200 {object} responsemodels.JSONResponseStruct{data=responsemodels.BulkPostResponse}
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/posts/bulk [post]
+func (h *PostBulkHandler) BulkPosts(c echo.Context) error {
+	authUser := c.Get("user").(models.User)
+
+	var req requestmodels.BulkPostRequest
+	if err := c.Bind(&req); err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid request body",
+				"Failed to bind request body",
+				err,
+			),
+			"",
+		)
+	}
+
+	req.Sanitize()
+	if err := requestmodels.Validate(req); err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	op := services.BulkPostOperation{
+		Action:     req.Action,
+		IDs:        req.IDs,
+		CategoryID: req.CategoryID,
+		Status:     req.Status,
+		Tags:       req.Tags,
+	}
+	if req.Filter != nil {
+		op.Filter = &repositories.PostFilter{
+			Search:        req.Filter.Search,
+			CategoryIDs:   req.Filter.CategoryIDs,
+			AuthorIDs:     req.Filter.AuthorIDs,
+			Statuses:      req.Filter.Statuses,
+			CreatedAfter:  req.Filter.CreatedAfter,
+			CreatedBefore: req.Filter.CreatedBefore,
+		}
+	}
+
+	results, err := h.service.Apply(op, authUser)
+	if err != nil {
+		return errors.HandleError(c, err, "Failed to apply bulk operation")
+	}
+
+	response := responsemodels.BulkPostResponse{Action: req.Action, Results: []responsemodels.BulkPostItemResult{}}
+	for _, result := range results {
+		response.Add(result.ID, result.Err)
+	}
+	return responsemodels.JSONResponse(c, http.StatusOK, "Bulk operation applied", response)
+}
@@ -2,13 +2,17 @@ package handlers
 
 import (
 	"crud_api/errors"
+	listquery "crud_api/list_query"
 	"crud_api/models"
+	"crud_api/repositories"
 	requestmodels "crud_api/request_models"
 	responsemodels "crud_api/response_models"
 	"crud_api/services"
 
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
 )
@@ -22,11 +26,16 @@ import (
 // }
 
 type PostHandler struct {
-	service services.PostService
+	service   services.PostService
+	reactions services.ReactionService
+	series    services.SeriesService
+	views     *services.ViewRecorder
+
+	requireIfMatch bool // reject edits and deletes that don't send the post's ETag
 }
 
-func NewPostHandler(service services.PostService) *PostHandler {
-	return &PostHandler{service}
+func NewPostHandler(service services.PostService, reactions services.ReactionService, series services.SeriesService, views *services.ViewRecorder, requireIfMatch bool) *PostHandler {
+	return &PostHandler{service, reactions, series, views, requireIfMatch}
 }
 
 // CreatePost godoc
@@ -81,21 +90,41 @@ func (h *PostHandler) CreatePost(c echo.Context) error {
 		return errors.HandleError(c, err, "")
 	}
 
+	if err := h.annotate(c, createdPost); err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
 	return responsemodels.JSONResponse(c, http.StatusCreated, "Successfully created post", responsemodels.ToPostResponse(*createdPost))
 }
 
 // GetPosts godoc
 // @Summary Get list of posts
-// @Description Get paginated list of posts with optional filters
+// @Description Get a list of posts with optional filters. The search term supports web search syntax ("quoted phrases", -excluded, OR); matches are ranked by relevance and carry highlighted fragments.
+// @Description Lists are paginated with opaque cursors: pass next_cursor as after, or prev_cursor as before. Passing page switches to the deprecated offset pagination, which returns a PaginatedResponse.
+// @Description Unknown or malformed query parameters are rejected with 400.
+// @Description Each post is listed once, in the language preferred through lang or Accept-Language when a translation exists, falling back to the default language and then to any other.
 // @Tags posts
 // @Accept json
 // @Produce json
-// @Param search query string false "Search term"
-// @Param category_id query string false "Filter by category ID"
-// @Param author_id query string false "Filter by author ID"
-// @Param page query int false "Page number" default(1)
+// @Param search query string false "Full-text search query"
+// @Param category_id query string false "Filter by category IDs (comma separated)"
+// @Param author_id query string false "Filter by author IDs (comma separated)"
+// @Param lang query string false "Preferred language, e.g. ne; overrides Accept-Language"
+// @Param Accept-Language header string false "Preferred languages, e.g. ne, en;q=0.8"
+// @Param created_after query string false "Only posts created at or after this date (YYYY-MM-DD or RFC 3339)"
+// @Param created_before query string false "Only posts created before this date (YYYY-MM-DD or RFC 3339)"
+// @Param updated_after query string false "Only posts updated at or after this date (YYYY-MM-DD or RFC 3339)"
+// @Param updated_before query string false "Only posts updated before this date (YYYY-MM-DD or RFC 3339)"
+// @Param sort query string false "Comma separated fields (created_at, updated_at, title, reactions, comments, relevance), prefixed with - for descending order, or one of newest, popular, relevance. Defaults to relevance when searching and newest otherwise."
+// @Param after query string false "Cursor returned as next_cursor by the previous page"
+// @Param before query string false "Cursor returned as prev_cursor by the next page"
+// @Param include_total query bool false "Also count all matching posts"
+// @Param page query int false "Page number (offset pagination, deprecated)"
 // @Param limit query int false "Items per page" default(10)
-// @Success 200 {object} responsemodels.PaginatedResponse{data=[]responsemodels.PostResponse}
+// @Param If-None-Match header string false "ETag of a cached copy; answered with 304 while it is current"
+// @Success 200 {object} responsemodels.JSONResponseStruct{data=responsemodels.CursorPaginatedResponse{data=[]responsemodels.PostResponse}}
+// @Success 304 "Not modified"
+// @Failure 400 {object} errors.ErrorResponse
 // @Failure 401 {object} errors.ErrorResponse
 // @Failure 403 {object} errors.ErrorResponse
 // @Failure 404 {object} errors.ErrorResponse
@@ -103,33 +132,35 @@ func (h *PostHandler) CreatePost(c echo.Context) error {
 // @Failure 500 {object} errors.ErrorResponse
 // @Router /v1/posts [get]
 func (h *PostHandler) GetPosts(c echo.Context) error {
-	search := c.QueryParam("search")
-	categoryID := c.QueryParam("category_id")
-	authorID := c.QueryParam("author_id")
-	p := responsemodels.GetPagination(c)
-
-	posts, total, err := h.service.GetAll(search, categoryID, authorID, p.Offset, p.Limit)
+	query, err := listquery.Parse(c.QueryParams(), postListSpec(true))
 	if err != nil {
 		return errors.HandleError(c, err, "")
 	}
 
-	var response []responsemodels.PostResponse
-	for _, post := range posts {
-		response = append(response, responsemodels.ToPostResponse(post))
-	}
+	filter := postFilter(query)
+	filter.AuthorIDs = query.Ints("author_id")
+	filter.Statuses = []string{models.PostStatusPublished}
 
-	paginated := responsemodels.NewPaginatedResponse(response, p.Page, p.Limit, total)
-	return responsemodels.SendPaginatedResponse(c, http.StatusOK, "Posts retrieved successfully", paginated)
+	// Each post is listed once, in the language the reader prefers
+	c.Response().Header().Add(echo.HeaderVary, "Accept-Language")
+	filter.Locales, err = h.service.NegotiateLocales(query.Text("lang"), c.Request().Header.Get("Accept-Language"))
+	if err != nil {
+		return errors.HandleError(c, err, "")
+	}
+	return h.listPosts(c, query, filter)
 }
 
 // PostDetails godoc
 // @Summary Get post details
-// @Description Get details of a specific post
+// @Description Get details of a specific post. Drafts and archived posts are only visible to their author and staff.
 // @Tags posts
 // @Accept json
 // @Produce json
 // @Param id path int true "Post ID"
 // @Success 200 {object} responsemodels.JSONResponseStruct{data=responsemodels.PostResponse}
+// @Param If-None-Match header string false "ETag of a cached copy; answered with 304 while it is current"
+// @Header 200 {string} ETag "Identifies this response; also usable as If-Match when editing or deleting the post"
+// @Success 304 "Not modified"
 // @Failure 401 {object} errors.ErrorResponse
 // @Failure 403 {object} errors.ErrorResponse
 // @Failure 404 {object} errors.ErrorResponse
@@ -143,23 +174,86 @@ func (h *PostHandler) PostDetails(c echo.Context) error {
 	if err != nil {
 		return errors.HandleError(c, err, "")
 	}
+	// Collaborators, reviewers included, can read the post before it is published
+	if !canSeePost(c, post) {
+		return errors.HandleError(c, errors.NotFound("Post not found", fmt.Sprintf("Post '%d' is not published", post.ID)), "")
+	}
+
+	if err := h.annotate(c, post); err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	// No Last-Modified: counters, series navigation and translations change without touching updated_at
+	if err := responsemodels.ConditionalJSONResponse(c, "Post retrieved successfully", responsemodels.ToPostResponse(*post), postVersion(*post), time.Time{}); err != nil {
+		return err
+	}
 
-	return responsemodels.JSONResponse(c, http.StatusOK, "Post retrieved successfully", responsemodels.ToPostResponse(*post))
+	// Revalidations answered with 304 and writers reading unpublished posts aren't views
+	if post.Status == models.PostStatusPublished && c.Response().Status == http.StatusOK {
+		h.views.Record(post.ID, c.RealIP(), c.Request().UserAgent())
+	}
+	return nil
+}
+
+// RelatedPosts godoc
+// @Summary Get related posts
+// @Description Get published posts in the same language that have the most in common with a post: the same category, shared tags and similar words. Translations of the post are left out. Only posts sharing the category or a tag are candidates. The ranking is stored per post and recomputed after the post or one of its candidates changes.
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param limit query int false "Number of posts (at most 20)" default(5)
+// @Param If-None-Match header string false "ETag of a cached copy; answered with 304 while it is current"
+// @Success 200 {object} responsemodels.JSONResponseStruct{data=[]responsemodels.PostResponse}
+// @Success 304 "Not modified"
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/posts/{id}/related [get]
+func (h *PostHandler) RelatedPosts(c echo.Context) error {
+	query, err := listquery.Parse(c.QueryParams(), listquery.Spec{Params: map[string]listquery.Kind{"limit": listquery.Int}})
+	if err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	id, _ := strconv.Atoi(c.Param("id"))
+	post, err := h.service.GetByID(uint(id))
+	if err != nil {
+		return errors.HandleError(c, err, "")
+	}
+	if !canSeePost(c, post) {
+		return errors.HandleError(c, errors.NotFound("Post not found", fmt.Sprintf("Post '%d' is not published", post.ID)), "")
+	}
+
+	posts, err := h.service.Related(post.ID, query.Int("limit"))
+	if err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	response, err := h.toPostResponses(c, posts)
+	if err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	return responsemodels.ConditionalJSONResponse(c, "Related posts retrieved successfully", response, "", time.Time{})
 }
 
 // PostDelete godoc
 // @Summary Delete a post
-// @Description Delete a post (only by author or admin)
+// @Description Move a post to the trash (only by its owners); it can be restored until the trash retention period ends
 // @Tags posts
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Post ID"
+// @Param If-Match header string false "ETag of the post as last fetched; required when the server enforces it"
 // @Success 200 {object} responsemodels.JSONResponseStruct
 // @Failure 401 {object} errors.ErrorResponse
 // @Failure 403 {object} errors.ErrorResponse
 // @Failure 404 {object} errors.ErrorResponse
 // @Failure 409 {object} errors.ErrorResponse
+// @Failure 412 {object} errors.ErrorResponse
+// @Failure 428 {object} errors.ErrorResponse
 // @Failure 500 {object} errors.ErrorResponse
 // @Router /v1/posts/{id} [delete]
 func (h *PostHandler) PostDelete(c echo.Context) error {
@@ -171,6 +265,10 @@ func (h *PostHandler) PostDelete(c echo.Context) error {
 		return errors.HandleError(c, err, "")
 	}
 
+	if err := checkIfMatch(c, *post, h.requireIfMatch); err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
 	if err := h.service.Delete(post, authUser.ID); err != nil {
 		return errors.HandleError(c, err, "")
 	}
@@ -180,49 +278,48 @@ func (h *PostHandler) PostDelete(c echo.Context) error {
 
 // PostEdit godoc
 // @Summary Update a post
-// @Description Update an existing post (only by author)
+// @Description Update an existing post (only by its owners and co-authors). Send only the fields to change, either as a JSON Merge Patch (application/merge-patch+json or application/json) or as a JSON Patch (application/json-patch+json, e.g. [{"op": "replace", "path": "/title", "value": "New title"}]). The patched post must still have a title, description and category.
 // @Tags posts
 // @Accept json
+// @Accept application/merge-patch+json
+// @Accept application/json-patch+json
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Post ID"
-// @Param post body requestmodels.UpdatePostRequest true "Updated post content"
+// @Param If-Match header string false "ETag of the post as last fetched; required when the server enforces it"
+// @Param post body requestmodels.UpdatePostRequest true "Fields to change"
 // @Success 200 {object} responsemodels.JSONResponseStruct{data=responsemodels.PostResponse}
+// @Failure 400 {object} errors.ErrorResponse
 // @Failure 401 {object} errors.ErrorResponse
 // @Failure 403 {object} errors.ErrorResponse
 // @Failure 404 {object} errors.ErrorResponse
 // @Failure 409 {object} errors.ErrorResponse
+// @Failure 412 {object} errors.ErrorResponse
+// @Failure 415 {object} errors.ErrorResponse
+// @Failure 428 {object} errors.ErrorResponse
 // @Failure 500 {object} errors.ErrorResponse
 // @Router /v1/posts/{id} [patch]
 func (h *PostHandler) PostEdit(c echo.Context) error {
 	authUser := c.Get("user").(models.User)
 	id, _ := strconv.Atoi(c.Param("id"))
 
-	var req requestmodels.UpdatePostRequest
-	if err := c.Bind(&req); err != nil {
-		return errors.HandleError(c,
-			errors.BadRequest(
-				"Invalid request body",
-				"Failed to bind request body",
-				err,
-			),
-			"",
-		)
+	post, err := h.service.GetByID(uint(id))
+	if err != nil {
+		return errors.HandleError(c, err, "")
 	}
 
-	if req.Title == "" || req.Description == "" {
-		return errors.HandleError(c,
-			errors.BadRequest(
-				"Post title and description is required",
-				"Client sent empty post title or description",
-				nil,
-			),
-			"",
-		)
+	if err := checkIfMatch(c, *post, h.requireIfMatch); err != nil {
+		return errors.HandleError(c, err, "")
 	}
 
-	post, err := h.service.GetByID(uint(id))
-	if err != nil {
+	var req requestmodels.UpdatePostRequest
+	if err := applyPatch(c, requestmodels.ToUpdatePostRequest(*post), &req); err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	req.Sanitize()
+
+	if err := requestmodels.Validate(req); err != nil {
 		return errors.HandleError(c, err, "")
 	}
 
@@ -237,20 +334,40 @@ func (h *PostHandler) PostEdit(c echo.Context) error {
 		return errors.HandleError(c, err, "")
 	}
 
+	if err := h.annotate(c, updatedPost); err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	c.Response().Header().Set(headerETag, postETag(*updatedPost))
 	return responsemodels.JSONResponse(c, http.StatusOK, "Post updated successfully", responsemodels.ToPostResponse(*updatedPost))
 }
 
 // GetPostsbyAuthor godoc
 // @Summary Get posts by author
-// @Description Get paginated list of posts by specific author
+// @Description Get a list of posts by a specific author; accepts the same filters, sorting and pagination as the post list.
+// @Description Only published posts are listed unless another status is requested, which only the author and staff may do.
 // @Tags posts
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param author_id path int true "Author ID"
-// @Param page query int false "Page number" default(1)
+// @Param search query string false "Full-text search query"
+// @Param category_id query string false "Filter by category IDs (comma separated)"
+// @Param status query string false "draft, published (default) or archived"
+// @Param created_after query string false "Only posts created at or after this date (YYYY-MM-DD or RFC 3339)"
+// @Param created_before query string false "Only posts created before this date (YYYY-MM-DD or RFC 3339)"
+// @Param updated_after query string false "Only posts updated at or after this date (YYYY-MM-DD or RFC 3339)"
+// @Param updated_before query string false "Only posts updated before this date (YYYY-MM-DD or RFC 3339)"
+// @Param sort query string false "Comma separated fields (created_at, updated_at, title, reactions, comments, relevance), prefixed with - for descending order, or one of newest, popular, relevance. Defaults to relevance when searching and newest otherwise."
+// @Param after query string false "Cursor returned as next_cursor by the previous page"
+// @Param before query string false "Cursor returned as prev_cursor by the next page"
+// @Param include_total query bool false "Also count all of the author's posts"
+// @Param page query int false "Page number (offset pagination, deprecated)"
 // @Param limit query int false "Items per page" default(10)
-// @Success 200 {object} responsemodels.PaginatedResponse{data=[]responsemodels.PostResponse}
+// @Param If-None-Match header string false "ETag of a cached copy; answered with 304 while it is current"
+// @Success 200 {object} responsemodels.JSONResponseStruct{data=responsemodels.CursorPaginatedResponse{data=[]responsemodels.PostResponse}}
+// @Success 304 "Not modified"
+// @Failure 400 {object} errors.ErrorResponse
 // @Failure 401 {object} errors.ErrorResponse
 // @Failure 403 {object} errors.ErrorResponse
 // @Failure 404 {object} errors.ErrorResponse
@@ -258,19 +375,156 @@ func (h *PostHandler) PostEdit(c echo.Context) error {
 // @Failure 500 {object} errors.ErrorResponse
 // @Router /v1/authors/{author_id}/posts [get]
 func (h *PostHandler) GetPostsbyAuthor(c echo.Context) error {
-	authorID := c.Param("author_id")
-	p := responsemodels.GetPagination(c)
+	authorID, err := strconv.Atoi(c.Param("author_id"))
+	if err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid author ID",
+				"Failed to parse author ID as integer",
+				err,
+			),
+			"",
+		)
+	}
 
-	posts, total, err := h.service.GetByAuthorID(authorID, p.Offset, p.Limit)
+	query, err := listquery.Parse(c.QueryParams(), postListSpec(false))
 	if err != nil {
 		return errors.HandleError(c, err, "")
 	}
 
-	var response []responsemodels.PostResponse
+	filter := postFilter(query)
+	filter.AuthorIDs = []int{authorID}
+	filter.Statuses = []string{models.PostStatusPublished}
+	if status := query.Text("status"); status != "" && status != models.PostStatusPublished {
+		if !canSeeUnpublished(c, uint(authorID)) {
+			return errors.HandleError(c,
+				errors.Forbidden("Only the author can list their unpublished posts", "Tried to list another author's unpublished posts"),
+				"",
+			)
+		}
+		filter.Statuses = []string{status}
+	}
+	return h.listPosts(c, query, filter)
+}
+
+// postListSpec whitelists the query parameters of post listings. The general listing filters by
+// author and negotiates the language; a single author's listing can instead ask for unpublished posts.
+func postListSpec(withAuthor bool) listquery.Spec {
+	params := map[string]listquery.Kind{
+		"search":         listquery.Text,
+		"category_id":    listquery.IntList,
+		"created_after":  listquery.DateTime,
+		"created_before": listquery.DateTime,
+		"updated_after":  listquery.DateTime,
+		"updated_before": listquery.DateTime,
+		"after":          listquery.Text,
+		"before":         listquery.Text,
+		"include_total":  listquery.Bool,
+		"page":           listquery.Int,
+		"limit":          listquery.Int,
+	}
+	if withAuthor {
+		params["author_id"] = listquery.IntList
+		params["lang"] = listquery.Text
+	} else {
+		params["status"] = listquery.Text
+	}
+	return listquery.Spec{Params: params, Sorts: repositories.PostSortFields, Aliases: repositories.PostSortAliases}
+}
+
+// postFilter builds the filter shared by post listings from validated query parameters
+func postFilter(query *listquery.Query) repositories.PostFilter {
+	return repositories.PostFilter{
+		Search:        query.Text("search"),
+		CategoryIDs:   query.Ints("category_id"),
+		CreatedAfter:  query.Time("created_after"),
+		CreatedBefore: query.Time("created_before"),
+		UpdatedAfter:  query.Time("updated_after"),
+		UpdatedBefore: query.Time("updated_before"),
+		Sort:          query.Sort,
+	}
+}
+
+// listPosts answers a post listing with cursor pagination, or with offset pagination when page is given.
+// Listings change with every new post, edit or reaction, so they are only validated by ETag.
+func (h *PostHandler) listPosts(c echo.Context, query *listquery.Query, filter repositories.PostFilter) error {
+	if query.Has("page") {
+		p := responsemodels.GetPagination(c)
+		posts, total, err := h.service.GetAll(filter, p.Offset, p.Limit)
+		if err != nil {
+			return errors.HandleError(c, err, "")
+		}
+
+		response, err := h.toPostResponses(c, posts)
+		if err != nil {
+			return errors.HandleError(c, err, "")
+		}
+
+		paginated := responsemodels.NewPaginatedResponse(response, p.Page, p.Limit, total)
+		return responsemodels.ConditionalJSONResponse(c, "Posts retrieved successfully", paginated, "", time.Time{})
+	}
+
+	p := responsemodels.GetCursorPagination(c)
+	after, err := repositories.DecodeCursor(p.After)
+	if err != nil {
+		return errors.HandleError(c, err, "")
+	}
+	before, err := repositories.DecodeCursor(p.Before)
+	if err != nil {
+		return errors.HandleError(c, err, "")
+	}
+	page, err := h.service.GetPage(filter, after, before, p.Limit, query.Bool("include_total"))
+	if err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	response, err := h.toPostResponses(c, page.Posts)
+	if err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	paginated := responsemodels.NewCursorPaginatedResponse(response, p.Limit, "")
+	if page.Next != nil {
+		paginated.NextCursor = page.Next.Encode()
+	}
+	if page.Prev != nil {
+		paginated.PrevCursor = page.Prev.Encode()
+	}
+	paginated.Total = page.Total
+	return responsemodels.ConditionalJSONResponse(c, "Posts retrieved successfully", paginated, "", time.Time{})
+}
+
+// toPostResponses annotates the posts with reactions, series navigation and translations and converts them for output
+func (h *PostHandler) toPostResponses(c echo.Context, posts []models.Post) ([]responsemodels.PostResponse, error) {
+	if err := h.reactions.Annotate(posts, viewerID(c)); err != nil {
+		return nil, err
+	}
+	if err := h.series.Annotate(posts); err != nil {
+		return nil, err
+	}
+	if err := h.service.AnnotateTranslations(posts); err != nil {
+		return nil, err
+	}
+
+	response := []responsemodels.PostResponse{}
 	for _, post := range posts {
 		response = append(response, responsemodels.ToPostResponse(post))
 	}
+	return response, nil
+}
 
-	paginated := responsemodels.NewPaginatedResponse(response, p.Page, p.Limit, total)
-	return responsemodels.SendPaginatedResponse(c, http.StatusOK, "Posts retrieved successfully", paginated)
+// annotate adds reaction counts (and the viewer's own reactions), the series navigation and the translations to a single post
+func (h *PostHandler) annotate(c echo.Context, post *models.Post) error {
+	posts := []models.Post{*post}
+	if err := h.reactions.Annotate(posts, viewerID(c)); err != nil {
+		return err
+	}
+	if err := h.series.Annotate(posts); err != nil {
+		return err
+	}
+	if err := h.service.AnnotateTranslations(posts); err != nil {
+		return err
+	}
+	*post = posts[0]
+	return nil
 }
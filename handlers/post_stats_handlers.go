@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"crud_api/errors"
+	"crud_api/models"
+	responsemodels "crud_api/response_models"
+	"crud_api/services"
+
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type PostStatsHandler struct {
+	service services.PostStatsService
+}
+
+// NewPostStatsHandler returns a new instance of PostStatsHandler
+func NewPostStatsHandler(service services.PostStatsService) *PostStatsHandler {
+	return &PostStatsHandler{service: service}
+}
+
+// GetPostStats godoc
+// @Summary Post view statistics
+// @Description Daily unique views of a post (bots excluded) for its author. Defaults to the last 30 days.
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Post ID"
+// @Param from query string false "First day (YYYY-MM-DD)"
+// @Param to query string false "Last day (YYYY-MM-DD), defaults to today"
+// @Success 200 {object} responsemodels.JSONResponseStruct{data=responsemodels.PostStatsResponse}
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 403 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/posts/{id}/stats [get]
+func (h *PostStatsHandler) GetPostStats(c echo.Context) error {
+	authUser := c.Get("user").(models.User)
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid post ID",
+				"Failed to parse post ID as integer",
+				err,
+			),
+			"",
+		)
+	}
+
+	to := time.Now().UTC()
+	if value := c.QueryParam("to"); value != "" {
+		if to, err = time.Parse("2006-01-02", value); err != nil {
+			return errors.HandleError(c, errors.BadRequest("'to' must be a date in YYYY-MM-DD format", "Failed to parse stats end date", err), "")
+		}
+	}
+
+	from := to.AddDate(0, 0, -29)
+	if value := c.QueryParam("from"); value != "" {
+		if from, err = time.Parse("2006-01-02", value); err != nil {
+			return errors.HandleError(c, errors.BadRequest("'from' must be a date in YYYY-MM-DD format", "Failed to parse stats start date", err), "")
+		}
+	}
+
+	stats, err := h.service.GetStats(uint(postID), authUser.ID, from, to)
+	if err != nil {
+		return errors.HandleError(c, err, "Failed to retrieve post statistics")
+	}
+
+	response := responsemodels.ToPostStatsResponse(stats.PostID, stats.TotalViews, stats.From, stats.To, stats.Series)
+	return responsemodels.JSONResponse(c, http.StatusOK, "Post statistics retrieved successfully", response)
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"crud_api/errors"
+	"crud_api/models"
+	requestmodels "crud_api/request_models"
+	responsemodels "crud_api/response_models"
+
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// LinkTranslation godoc
+// @Summary Link a translation
+// @Description Mark another post as a translation of this one, joining its translation group. Both posts must be yours to edit and a group has at most one post per language.
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Post ID"
+// @Param translation body requestmodels.PostTranslationRequest true "Post to link"
+// @Success 200 {object} responsemodels.JSONResponseStruct{data=responsemodels.PostResponse}
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 403 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 409 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/posts/{id}/translations [post]
+func (h *PostHandler) LinkTranslation(c echo.Context) error {
+	authUser := c.Get("user").(models.User)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid post ID",
+				"Failed to parse post ID as integer",
+				err,
+			),
+			"",
+		)
+	}
+
+	var req requestmodels.PostTranslationRequest
+	if err := c.Bind(&req); err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid request body",
+				"Failed to bind request body",
+				err,
+			),
+			"",
+		)
+	}
+
+	if err := requestmodels.Validate(req); err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	if err := h.service.LinkTranslation(uint(id), req.PostID, authUser.ID); err != nil {
+		return errors.HandleError(c, err, "Failed to link translation")
+	}
+
+	return h.respondWithPost(c, uint(id), "Translation linked successfully")
+}
+
+// UnlinkTranslation godoc
+// @Summary Unlink a translation
+// @Description Take the post out of its translation group; the other translations stay linked to each other
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Post ID"
+// @Success 200 {object} responsemodels.JSONResponseStruct{data=responsemodels.PostResponse}
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 403 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/posts/{id}/translations [delete]
+func (h *PostHandler) UnlinkTranslation(c echo.Context) error {
+	authUser := c.Get("user").(models.User)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid post ID",
+				"Failed to parse post ID as integer",
+				err,
+			),
+			"",
+		)
+	}
+
+	if err := h.service.UnlinkTranslation(uint(id), authUser.ID); err != nil {
+		return errors.HandleError(c, err, "Failed to unlink translation")
+	}
+
+	return h.respondWithPost(c, uint(id), "Translation unlinked successfully")
+}
+
+// respondWithPost returns the post as it is after a change
+func (h *PostHandler) respondWithPost(c echo.Context, id uint, message string) error {
+	post, err := h.service.GetByID(id)
+	if err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	if err := h.annotate(c, post); err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	return responsemodels.JSONResponse(c, http.StatusOK, message, responsemodels.ToPostResponse(*post))
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"crud_api/errors"
+	"crud_api/models"
+	"fmt"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	headerETag    = "ETag"
+	headerIfMatch = "If-Match"
+)
+
+// postVersion identifies the stored version of a post. The ETag of PostDetails starts with it,
+// followed by "-" and a hash of the response body that changes with counters and viewer data.
+func postVersion(post models.Post) string {
+	return fmt.Sprintf("%d.%d", post.ID, post.Version)
+}
+
+// postETag is the ETag of responses that only reflect the post's stored version
+func postETag(post models.Post) string {
+	return `"` + postVersion(post) + `"`
+}
+
+// checkIfMatch compares the If-Match header with the post as currently stored. Without the
+// header the request goes through unless required is set.
+func checkIfMatch(c echo.Context, post models.Post, required bool) error {
+	header := c.Request().Header.Get(headerIfMatch)
+	if header == "" {
+		if required {
+			return errors.PreconditionRequired(
+				"This request requires an If-Match header with the post's ETag",
+				"Client changed a post without If-Match",
+			)
+		}
+		return nil
+	}
+
+	current := postVersion(post)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return nil
+		}
+		// Weak tags never match: If-Match uses strong comparison
+		if opaque, ok := strings.CutPrefix(tag, `"`); ok {
+			version, _, _ := strings.Cut(strings.TrimSuffix(opaque, `"`), "-")
+			if version == current {
+				return nil
+			}
+		}
+	}
+	return errors.PreconditionFailed(
+		"The post was changed since you fetched it; fetch it again and retry",
+		fmt.Sprintf("If-Match %s does not match %s", header, current),
+	)
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"crud_api/errors"
+	"crud_api/models"
+	responsemodels "crud_api/response_models"
+	"crud_api/services"
+
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type ReactionHandler struct {
+	service services.ReactionService
+}
+
+// NewReactionHandler returns a new instance of ReactionHandler
+func NewReactionHandler(service services.ReactionService) *ReactionHandler {
+	return &ReactionHandler{service: service}
+}
+
+// AddReaction godoc
+// @Summary React to a post
+// @Description Add a reaction of the given type to a post. Each user can leave one reaction of each type; repeating it has no effect. Drafts and archived posts can only be reacted to by their writers and staff.
+// @Tags reactions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Post ID"
+// @Param type path string true "Reaction type" Enums(like, love, laugh, wow, sad, angry)
+// @Success 200 {object} responsemodels.JSONResponseStruct{data=responsemodels.ReactionSummary}
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/posts/{id}/reactions/{type} [put]
+func (h *ReactionHandler) AddReaction(c echo.Context) error {
+	authUser := c.Get("user").(models.User)
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid post ID",
+				"Failed to parse post ID as integer",
+				err,
+			),
+			"",
+		)
+	}
+
+	if err := h.service.React(uint(postID), authUser, c.Param("type")); err != nil {
+		return errors.HandleError(c, err, "Failed to add reaction")
+	}
+
+	return h.respondWithSummary(c, uint(postID), authUser.ID, "Reaction added successfully")
+}
+
+// RemoveReaction godoc
+// @Summary Remove a reaction from a post
+// @Description Remove your reaction of the given type from a post. Removing a reaction you didn't leave has no effect.
+// @Tags reactions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Post ID"
+// @Param type path string true "Reaction type" Enums(like, love, laugh, wow, sad, angry)
+// @Success 200 {object} responsemodels.JSONResponseStruct{data=responsemodels.ReactionSummary}
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/posts/{id}/reactions/{type} [delete]
+func (h *ReactionHandler) RemoveReaction(c echo.Context) error {
+	authUser := c.Get("user").(models.User)
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid post ID",
+				"Failed to parse post ID as integer",
+				err,
+			),
+			"",
+		)
+	}
+
+	if err := h.service.Unreact(uint(postID), authUser.ID, c.Param("type")); err != nil {
+		return errors.HandleError(c, err, "Failed to remove reaction")
+	}
+
+	return h.respondWithSummary(c, uint(postID), authUser.ID, "Reaction removed successfully")
+}
+
+func (h *ReactionHandler) respondWithSummary(c echo.Context, postID, userID uint, message string) error {
+	counts, mine, err := h.service.Summary(postID, userID)
+	if err != nil {
+		return errors.HandleError(c, err, "")
+	}
+	return responsemodels.JSONResponse(c, http.StatusOK, message, responsemodels.ToReactionSummary(counts, mine))
+}
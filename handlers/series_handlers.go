@@ -0,0 +1,373 @@
+package handlers
+
+import (
+	"crud_api/errors"
+	"crud_api/models"
+	requestmodels "crud_api/request_models"
+	responsemodels "crud_api/response_models"
+	"crud_api/services"
+
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type SeriesHandler struct {
+	service services.SeriesService
+}
+
+// NewSeriesHandler returns a new instance of SeriesHandler
+func NewSeriesHandler(service services.SeriesService) *SeriesHandler {
+	return &SeriesHandler{service: service}
+}
+
+// CreateSeries godoc
+// @Summary Create a series
+// @Description Create a series to group your posts into a multi-part collection, e.g. a tutorial. Posts are added to it afterwards.
+// @Tags series
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param series body requestmodels.SeriesRequest true "Series title and description"
+// @Success 201 {object} responsemodels.JSONResponseStruct{data=responsemodels.SeriesResponse}
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/series [post]
+func (h *SeriesHandler) CreateSeries(c echo.Context) error {
+	authUser := c.Get("user").(models.User)
+
+	var req requestmodels.SeriesRequest
+	if err := c.Bind(&req); err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid request body",
+				"Failed to bind request body",
+				err,
+			),
+			"",
+		)
+	}
+
+	req.Sanitize()
+
+	if err := requestmodels.Validate(req); err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	series := requestmodels.FromSeriesRequest(req, authUser.ID)
+	if err := h.service.Create(&series); err != nil {
+		return errors.HandleError(c, err, "Failed to create series")
+	}
+
+	return responsemodels.JSONResponse(c, http.StatusCreated, "Series created successfully", responsemodels.ToSeriesResponse(series))
+}
+
+// GetSeries godoc
+// @Summary Get a series
+// @Description Get a series with its posts in reading order. Drafts and archived posts are only listed for the owner and staff.
+// @Tags series
+// @Accept json
+// @Produce json
+// @Param id path int true "Series ID"
+// @Success 200 {object} responsemodels.JSONResponseStruct{data=responsemodels.SeriesResponse}
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/series/{id} [get]
+func (h *SeriesHandler) GetSeries(c echo.Context) error {
+	id, err := parseSeriesID(c)
+	if err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	series, err := h.service.GetByID(id)
+	if err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	posts, err := h.service.Posts(series, canSeeUnpublished(c, series.OwnerID))
+	if err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	return responsemodels.JSONResponse(c, http.StatusOK, "Series retrieved successfully", responsemodels.ToSeriesDetailsResponse(*series, posts))
+}
+
+// ListMySeries godoc
+// @Summary List own series
+// @Description Get the series you created, newest first
+// @Tags series
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Success 200 {object} responsemodels.PaginatedResponse{data=[]responsemodels.SeriesResponse}
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/users/me/series [get]
+func (h *SeriesHandler) ListMySeries(c echo.Context) error {
+	authUser := c.Get("user").(models.User)
+	p := responsemodels.GetPagination(c)
+
+	series, total, err := h.service.List(authUser.ID, p.Limit, p.Offset)
+	if err != nil {
+		return errors.HandleError(c, err, "Failed to retrieve series")
+	}
+
+	response := []responsemodels.SeriesResponse{}
+	for _, s := range series {
+		response = append(response, responsemodels.ToSeriesResponse(s))
+	}
+
+	paginated := responsemodels.NewPaginatedResponse(response, p.Page, p.Limit, total)
+	return responsemodels.SendPaginatedResponse(c, http.StatusOK, "Series retrieved successfully", paginated)
+}
+
+// EditSeries godoc
+// @Summary Update a series
+// @Description Update the title or description of a series (only by its owner). Send only the fields to change, as a JSON Merge Patch or a JSON Patch.
+// @Tags series
+// @Accept json
+// @Accept application/merge-patch+json
+// @Accept application/json-patch+json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Series ID"
+// @Param series body requestmodels.SeriesRequest true "Fields to change"
+// @Success 200 {object} responsemodels.JSONResponseStruct{data=responsemodels.SeriesResponse}
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 403 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 415 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/series/{id} [patch]
+func (h *SeriesHandler) EditSeries(c echo.Context) error {
+	authUser := c.Get("user").(models.User)
+	id, err := parseSeriesID(c)
+	if err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	series, err := h.service.GetByID(id)
+	if err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	var req requestmodels.SeriesRequest
+	if err := applyPatch(c, requestmodels.ToSeriesRequest(*series), &req); err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	req.Sanitize()
+
+	if err := requestmodels.Validate(req); err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	requestmodels.ApplySeriesRequest(series, req)
+
+	if err := h.service.Update(series, authUser.ID); err != nil {
+		return errors.HandleError(c, err, "Failed to update series")
+	}
+
+	return responsemodels.JSONResponse(c, http.StatusOK, "Series updated successfully", responsemodels.ToSeriesResponse(*series))
+}
+
+// DeleteSeries godoc
+// @Summary Delete a series
+// @Description Delete a series (only by its owner). Its posts are kept and no longer belong to a series.
+// @Tags series
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Series ID"
+// @Success 200 {object} responsemodels.JSONResponseStruct
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 403 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/series/{id} [delete]
+func (h *SeriesHandler) DeleteSeries(c echo.Context) error {
+	authUser := c.Get("user").(models.User)
+	id, err := parseSeriesID(c)
+	if err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	series, err := h.service.GetByID(id)
+	if err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	if err := h.service.Delete(series, authUser.ID); err != nil {
+		return errors.HandleError(c, err, "Failed to delete series")
+	}
+
+	return responsemodels.JSONResponse(c, http.StatusOK, "Series deleted successfully", nil)
+}
+
+// AddSeriesPost godoc
+// @Summary Add a post to a series
+// @Description Add one of your posts to your series as its last part. A post can be part of one series at a time; adding it again to the same series changes nothing.
+// @Tags series
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Series ID"
+// @Param post body requestmodels.SeriesPostRequest true "Post to add"
+// @Success 200 {object} responsemodels.JSONResponseStruct{data=responsemodels.SeriesResponse}
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 403 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 409 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/series/{id}/posts [post]
+func (h *SeriesHandler) AddSeriesPost(c echo.Context) error {
+	authUser := c.Get("user").(models.User)
+	id, err := parseSeriesID(c)
+	if err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	var req requestmodels.SeriesPostRequest
+	if err := c.Bind(&req); err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid request body",
+				"Failed to bind request body",
+				err,
+			),
+			"",
+		)
+	}
+
+	if err := requestmodels.Validate(req); err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	if err := h.service.AddPost(id, req.PostID, authUser.ID); err != nil {
+		return errors.HandleError(c, err, "Failed to add post to series")
+	}
+
+	return h.respondWithPosts(c, id, "Post added to series successfully")
+}
+
+// RemoveSeriesPost godoc
+// @Summary Remove a post from a series
+// @Description Take a post out of your series; the post itself is kept and the remaining parts close the gap
+// @Tags series
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Series ID"
+// @Param post_id path int true "Post ID"
+// @Success 200 {object} responsemodels.JSONResponseStruct{data=responsemodels.SeriesResponse}
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 403 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/series/{id}/posts/{post_id} [delete]
+func (h *SeriesHandler) RemoveSeriesPost(c echo.Context) error {
+	authUser := c.Get("user").(models.User)
+	id, err := parseSeriesID(c)
+	if err != nil {
+		return errors.HandleError(c, err, "")
+	}
+	postID, err := strconv.Atoi(c.Param("post_id"))
+	if err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid post ID",
+				"Failed to parse post ID as integer",
+				err,
+			),
+			"",
+		)
+	}
+
+	if err := h.service.RemovePost(id, uint(postID), authUser.ID); err != nil {
+		return errors.HandleError(c, err, "Failed to remove post from series")
+	}
+
+	return h.respondWithPosts(c, id, "Post removed from series successfully")
+}
+
+// ReorderSeries godoc
+// @Summary Reorder a series
+// @Description Set the reading order of your series. The list must contain every post of the series exactly once, drafts included.
+// @Tags series
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Series ID"
+// @Param order body requestmodels.SeriesOrderRequest true "Post IDs in reading order"
+// @Success 200 {object} responsemodels.JSONResponseStruct{data=responsemodels.SeriesResponse}
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 403 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/series/{id}/order [put]
+func (h *SeriesHandler) ReorderSeries(c echo.Context) error {
+	authUser := c.Get("user").(models.User)
+	id, err := parseSeriesID(c)
+	if err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	var req requestmodels.SeriesOrderRequest
+	if err := c.Bind(&req); err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid request body",
+				"Failed to bind request body",
+				err,
+			),
+			"",
+		)
+	}
+
+	if err := requestmodels.Validate(req); err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	if err := h.service.Reorder(id, req.PostIDs, authUser.ID); err != nil {
+		return errors.HandleError(c, err, "Failed to reorder series")
+	}
+
+	return h.respondWithPosts(c, id, "Series reordered successfully")
+}
+
+// respondWithPosts returns the series with all its posts, as seen by its owner after a change
+func (h *SeriesHandler) respondWithPosts(c echo.Context, id uint, message string) error {
+	series, err := h.service.GetByID(id)
+	if err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	posts, err := h.service.Posts(series, true)
+	if err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	return responsemodels.JSONResponse(c, http.StatusOK, message, responsemodels.ToSeriesDetailsResponse(*series, posts))
+}
+
+func parseSeriesID(c echo.Context) (uint, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return 0, errors.BadRequest(
+			"Invalid series ID",
+			"Failed to parse series ID as integer",
+			err,
+		)
+	}
+	return uint(id), nil
+}
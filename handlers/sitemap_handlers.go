@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"crud_api/errors"
+	responsemodels "crud_api/response_models"
+	"crud_api/services"
+	"crud_api/sitemap"
+
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type SitemapHandler struct {
+	service services.SitemapService
+}
+
+// NewSitemapHandler returns a new instance of SitemapHandler
+func NewSitemapHandler(service services.SitemapService) *SitemapHandler {
+	return &SitemapHandler{service: service}
+}
+
+// Sitemap godoc
+// @Summary Sitemap
+// @Description Lists the public pages of published posts, categories and authors with their last modification.
+// @Description Above 50,000 URLs a sitemap index pointing at /sitemaps/{page}.xml is returned instead.
+// @Tags sitemap
+// @Produce xml
+// @Param If-None-Match header string false "ETag of a cached copy; answered with 304 while it is current"
+// @Success 200 {string} string "Sitemap or sitemap index"
+// @Success 304 "Not modified"
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /sitemap.xml [get]
+func (h *SitemapHandler) Sitemap(c echo.Context) error {
+	pages, err := h.service.Pages()
+	if err != nil {
+		return errors.HandleError(c, err, "Failed to build sitemap")
+	}
+	if pages == 1 {
+		return h.sendPage(c, 1)
+	}
+
+	index, err := h.service.Index()
+	if err != nil {
+		return errors.HandleError(c, err, "Failed to build sitemap")
+	}
+	body, err := sitemap.RenderIndex(index)
+	if err != nil {
+		return errors.HandleError(c, errors.Internal("Failed to build sitemap", "Error rendering sitemap index", err), "")
+	}
+	return responsemodels.ConditionalBlobResponse(c, sitemap.ContentType, body, "", time.Time{})
+}
+
+// SitemapPage godoc
+// @Summary Sitemap page
+// @Description One sitemap of the index served at /sitemap.xml when the blog has more than 50,000 pages
+// @Tags sitemap
+// @Produce xml
+// @Param page path string true "Page number followed by .xml, e.g. 2.xml"
+// @Param If-None-Match header string false "ETag of a cached copy; answered with 304 while it is current"
+// @Success 200 {string} string "Sitemap"
+// @Success 304 "Not modified"
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /sitemaps/{page} [get]
+func (h *SitemapHandler) SitemapPage(c echo.Context) error {
+	number, ok := strings.CutSuffix(c.Param("page"), ".xml")
+	page, err := strconv.Atoi(number)
+	if !ok || err != nil {
+		return errors.HandleError(c, errors.NotFound("Sitemap not found", "Client requested malformed sitemap page '"+c.Param("page")+"'"), "")
+	}
+	return h.sendPage(c, page)
+}
+
+func (h *SitemapHandler) sendPage(c echo.Context, page int) error {
+	urls, err := h.service.Page(page)
+	if err != nil {
+		return errors.HandleError(c, err, "Failed to build sitemap")
+	}
+	body, err := sitemap.Render(urls)
+	if err != nil {
+		return errors.HandleError(c, errors.Internal("Failed to build sitemap", "Error rendering sitemap", err), "")
+	}
+	return responsemodels.ConditionalBlobResponse(c, sitemap.ContentType, body, "", time.Time{})
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"crud_api/errors"
+	"crud_api/models"
+	responsemodels "crud_api/response_models"
+	"crud_api/services"
+
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type TrashHandler struct {
+	service   services.TrashService
+	reactions services.ReactionService
+}
+
+// NewTrashHandler returns a new instance of TrashHandler
+func NewTrashHandler(service services.TrashService, reactions services.ReactionService) *TrashHandler {
+	return &TrashHandler{service: service, reactions: reactions}
+}
+
+// ListTrash godoc
+// @Summary List trashed posts
+// @Description Get the deleted posts you own, as author or owner, most recently deleted first, with the time each will be permanently deleted
+// @Tags trash
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Success 200 {object} responsemodels.PaginatedResponse{data=[]responsemodels.TrashedPostResponse}
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/users/me/trash [get]
+func (h *TrashHandler) ListTrash(c echo.Context) error {
+	authUser := c.Get("user").(models.User)
+	p := responsemodels.GetPagination(c)
+
+	posts, total, err := h.service.List(authUser.ID, p.Offset, p.Limit)
+	if err != nil {
+		return errors.HandleError(c, err, "Failed to retrieve trash")
+	}
+
+	response := []responsemodels.TrashedPostResponse{}
+	for _, post := range posts {
+		response = append(response, responsemodels.ToTrashedPostResponse(post, h.service.Retention()))
+	}
+
+	paginated := responsemodels.NewPaginatedResponse(response, p.Page, p.Limit, total)
+	return responsemodels.SendPaginatedResponse(c, http.StatusOK, "Trash retrieved successfully", paginated)
+}
+
+// RestorePost godoc
+// @Summary Restore a trashed post
+// @Description Move a deleted post out of the trash (only by its owners or an admin). Fails if a live post by the same author now has the same title.
+// @Tags trash
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Post ID"
+// @Success 200 {object} responsemodels.JSONResponseStruct{data=responsemodels.PostResponse}
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 403 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 409 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/posts/{id}/restore [post]
+func (h *TrashHandler) RestorePost(c echo.Context) error {
+	authUser := c.Get("user").(models.User)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid post ID",
+				"Failed to parse post ID as integer",
+				err,
+			),
+			"",
+		)
+	}
+
+	post, err := h.service.Restore(uint(id), authUser)
+	if err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	posts := []models.Post{*post}
+	if err := h.reactions.Annotate(posts, authUser.ID); err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	return responsemodels.JSONResponse(c, http.StatusOK, "Post restored successfully", responsemodels.ToPostResponse(posts[0]))
+}
+
+// PurgePost godoc
+// @Summary Permanently delete a post
+// @Description Permanently delete a post, trashed or not, with its comments, reactions, bookmarks and statistics (admins only)
+// @Tags trash
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Post ID"
+// @Success 200 {object} responsemodels.JSONResponseStruct
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 403 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/posts/{id}/purge [delete]
+func (h *TrashHandler) PurgePost(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid post ID",
+				"Failed to parse post ID as integer",
+				err,
+			),
+			"",
+		)
+	}
+
+	if err := h.service.Purge(uint(id)); err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	return responsemodels.JSONResponse(c, http.StatusOK, "Post permanently deleted", nil)
+}
@@ -2,11 +2,14 @@ package handlers
 
 import (
 	"crud_api/errors"
+	"crud_api/models"
 	requestmodels "crud_api/request_models"
 	responsemodels "crud_api/response_models"
 	"crud_api/services"
 
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 )
@@ -143,3 +146,141 @@ func (h *UserHandler) GetAllUsers(c echo.Context) error {
 
 	return responsemodels.JSONResponse(c, http.StatusOK, "Successfully retrieved users", response)
 }
+
+// UpdateUserRole godoc
+// @Summary Change a user's role
+// @Description Grant or revoke moderator/admin rights (admins only)
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param role body requestmodels.UpdateRoleRequest true "New role"
+// @Success 200 {object} responsemodels.JSONResponseStruct{data=responsemodels.UserResponse}
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 403 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/users/{id}/role [patch]
+func (h *UserHandler) UpdateUserRole(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid user ID",
+				"Failed to parse user ID as integer",
+				err,
+			),
+			"",
+		)
+	}
+
+	var req requestmodels.UpdateRoleRequest
+	if err := c.Bind(&req); err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid request body",
+				"Failed to bind request body",
+				err,
+			),
+			"",
+		)
+	}
+
+	user, err := h.service.UpdateRole(uint(id), strings.TrimSpace(req.Role))
+	if err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	return responsemodels.JSONResponse(c, http.StatusOK, "User role updated successfully", responsemodels.ToUserResponse(*user))
+}
+
+// UpdateUserStatus godoc
+// @Summary Disable or enable a user
+// @Description Disabled users can't log in and their existing tokens stop working (admins only)
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param status body requestmodels.UpdateUserStatusRequest true "New status"
+// @Success 200 {object} responsemodels.JSONResponseStruct{data=responsemodels.UserResponse}
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 403 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/users/{id}/status [patch]
+func (h *UserHandler) UpdateUserStatus(c echo.Context) error {
+	authUser := c.Get("user").(models.User)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid user ID",
+				"Failed to parse user ID as integer",
+				err,
+			),
+			"",
+		)
+	}
+
+	var req requestmodels.UpdateUserStatusRequest
+	if err := c.Bind(&req); err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid request body",
+				"Failed to bind request body",
+				err,
+			),
+			"",
+		)
+	}
+
+	user, err := h.service.SetDisabled(uint(id), req.Disabled, authUser)
+	if err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	message := "User enabled successfully"
+	if user.Disabled {
+		message = "User disabled successfully"
+	}
+	return responsemodels.JSONResponse(c, http.StatusOK, message, responsemodels.ToUserResponse(*user))
+}
+
+// DeleteUser godoc
+// @Summary Delete a user
+// @Description Delete a user account; its tokens stop working immediately (admins only)
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} responsemodels.JSONResponseStruct
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 403 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /v1/users/{id} [delete]
+func (h *UserHandler) DeleteUser(c echo.Context) error {
+	authUser := c.Get("user").(models.User)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return errors.HandleError(c,
+			errors.BadRequest(
+				"Invalid user ID",
+				"Failed to parse user ID as integer",
+				err,
+			),
+			"",
+		)
+	}
+
+	if err := h.service.Delete(uint(id), authUser); err != nil {
+		return errors.HandleError(c, err, "")
+	}
+
+	return responsemodels.JSONResponse(c, http.StatusOK, "User deleted successfully", nil)
+}
@@ -0,0 +1,199 @@
+package imageprocessing
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// StripMetadata removes EXIF, XMP, IPTC and text metadata from JPEG, PNG and WebP files
+// without re-encoding them. Colour profiles are kept. It returns the data unchanged, and
+// false, when there was nothing to remove or the format isn't understood.
+func StripMetadata(data []byte, contentType string) ([]byte, bool) {
+	switch contentType {
+	case "image/jpeg":
+		return stripJPEG(data)
+	case "image/png":
+		return stripPNG(data)
+	case "image/webp":
+		return stripWebP(data)
+	}
+	return data, false
+}
+
+// JPEG markers
+const (
+	markerAPP1  = 0xE1 // EXIF and XMP
+	markerAPP13 = 0xED // Photoshop resources, including IPTC
+	markerCOM   = 0xFE // comments
+	markerSOS   = 0xDA // start of scan: entropy coded data follows
+)
+
+// jpegSegments calls fn for each marker segment before the image data, with the whole
+// segment including marker and length, and returns the offset of the start of scan
+func jpegSegments(data []byte, fn func(marker byte, segment []byte)) (int, bool) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 0, false
+	}
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return 0, false
+		}
+		marker := data[pos+1]
+		if marker == 0xFF { // fill byte
+			pos++
+			continue
+		}
+		if marker == markerSOS {
+			return pos, true
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return 0, false
+		}
+		fn(marker, data[pos:end])
+		pos = end
+	}
+	return 0, false
+}
+
+func stripJPEG(data []byte) ([]byte, bool) {
+	var kept bytes.Buffer
+	kept.Write(data[:2])
+	changed := false
+	scan, ok := jpegSegments(data, func(marker byte, segment []byte) {
+		if marker == markerAPP1 || marker == markerAPP13 || marker == markerCOM {
+			changed = true
+			return
+		}
+		kept.Write(segment)
+	})
+	if !ok || !changed {
+		return data, false
+	}
+	kept.Write(data[scan:])
+	return kept.Bytes(), true
+}
+
+// Orientation returns the EXIF orientation (1 to 8) of a JPEG file, or 1 when it has none
+func Orientation(data []byte) int {
+	orientation := 1
+	jpegSegments(data, func(marker byte, segment []byte) {
+		if marker != markerAPP1 || !bytes.HasPrefix(segment[4:], []byte("Exif\x00\x00")) {
+			return
+		}
+		if value, ok := exifOrientation(segment[10:]); ok {
+			orientation = value
+		}
+	})
+	return orientation
+}
+
+// exifOrientation reads the orientation tag from the first IFD of a TIFF structure
+func exifOrientation(tiff []byte) (int, bool) {
+	if len(tiff) < 8 {
+		return 0, false
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0, false
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 0, false
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0, false
+		}
+		const tagOrientation, typeShort = 0x0112, 3
+		if order.Uint16(tiff[entry:]) == tagOrientation && order.Uint16(tiff[entry+2:]) == typeShort {
+			value := int(order.Uint16(tiff[entry+8:]))
+			return value, value >= 1 && value <= 8
+		}
+	}
+	return 0, false
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// pngMetadataChunks are dropped from PNG files
+var pngMetadataChunks = map[string]bool{"eXIf": true, "tEXt": true, "zTXt": true, "iTXt": true, "tIME": true}
+
+func stripPNG(data []byte) ([]byte, bool) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return data, false
+	}
+	var kept bytes.Buffer
+	kept.Write(pngSignature)
+	changed := false
+	for pos := len(pngSignature); pos < len(data); {
+		if pos+12 > len(data) {
+			return data, false
+		}
+		end := pos + 12 + int(binary.BigEndian.Uint32(data[pos:]))
+		if end > len(data) || end < pos {
+			return data, false
+		}
+		if pngMetadataChunks[string(data[pos+4:pos+8])] {
+			changed = true
+		} else {
+			kept.Write(data[pos:end])
+		}
+		pos = end
+	}
+	if !changed {
+		return data, false
+	}
+	return kept.Bytes(), true
+}
+
+// VP8X flags announcing metadata chunks
+const (
+	webpFlagEXIF = 0x08
+	webpFlagXMP  = 0x04
+)
+
+func stripWebP(data []byte) ([]byte, bool) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return data, false
+	}
+	kept := bytes.NewBuffer(append([]byte(nil), data[:12]...))
+	changed := false
+	for pos := 12; pos < len(data); {
+		if pos+8 > len(data) {
+			return data, false
+		}
+		size := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		end := pos + 8 + size + size%2 // chunks are padded to an even size
+		if end > len(data) || end < pos {
+			return data, false
+		}
+		switch string(data[pos : pos+4]) {
+		case "EXIF", "XMP ":
+			changed = true
+		default:
+			kept.Write(data[pos:end])
+		}
+		pos = end
+	}
+	if !changed {
+		return data, false
+	}
+
+	out := kept.Bytes()
+	if string(out[12:16]) == "VP8X" && len(out) > 20 {
+		out[20] &^= webpFlagEXIF | webpFlagXMP
+	}
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	return out, true
+}
@@ -0,0 +1,217 @@
+// Package imageprocessing prepares uploaded images for the web: it removes metadata, applies
+// the EXIF orientation and renders resized and WebP variants
+package imageprocessing
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // registers the GIF decoder
+	"image/jpeg"
+	"image/png"
+	"sort"
+	"strconv"
+
+	"github.com/HugoSmits86/nativewebp"
+	"github.com/disintegration/imaging"
+	_ "golang.org/x/image/webp" // registers the WebP decoder
+)
+
+// ErrUnsupported is returned for files that aren't images this package can decode
+var ErrUnsupported = errors.New("imageprocessing: unsupported image type")
+
+// Variant is a rendition of the uploaded image. Name is unique per image, e.g. "640w.jpg".
+type Variant struct {
+	Name        string
+	Width       int
+	Height      int
+	ContentType string
+	Data        []byte
+}
+
+// Result of processing an image
+type Result struct {
+	Width    int // of the upright original
+	Height   int
+	Original []byte // replacement for the original without metadata; nil when it needs no change
+	Variants []Variant
+}
+
+// Processor renders the variants of uploaded images
+type Processor struct {
+	Widths      []int // widths of the resized variants; only those smaller than the original are rendered
+	JPEGQuality int   // quality of re-encoded JPEG files, 1 to 100
+	MaxPixels   int   // larger images are rejected rather than decoded into memory
+}
+
+// Process strips the metadata of the image, turns it upright and renders its variants. Resized
+// variants keep JPEG for photos and use PNG for everything else. WebP variants are encoded
+// losslessly and only kept when they are smaller than the variant they duplicate.
+func (p Processor) Process(data []byte, contentType string) (*Result, error) {
+	if !Supported(contentType) {
+		return nil, ErrUnsupported
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("imageprocessing: reading image header: %w", err)
+	}
+	if p.MaxPixels > 0 && config.Width*config.Height > p.MaxPixels {
+		return nil, fmt.Errorf("imageprocessing: image of %dx%d pixels is too large", config.Width, config.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("imageprocessing: decoding image: %w", err)
+	}
+
+	result := &Result{}
+	original := data
+	if contentType == "image/jpeg" {
+		// Stripping the metadata drops the orientation too, so turned photos are re-encoded upright
+		if orientation := Orientation(data); orientation != 1 {
+			img = orient(img, orientation)
+			if original, err = p.encode(img, "image/jpeg"); err != nil {
+				return nil, err
+			}
+			result.Original = original
+		}
+	}
+	if result.Original == nil {
+		if stripped, changed := StripMetadata(data, contentType); changed {
+			original = stripped
+			result.Original = stripped
+		}
+	}
+
+	bounds := img.Bounds()
+	result.Width, result.Height = bounds.Dx(), bounds.Dy()
+
+	// Resized variants are JPEG for photos; PNG keeps transparency and sharp edges for the rest
+	format, extension := "image/png", "png"
+	if contentType == "image/jpeg" {
+		format, extension = "image/jpeg", "jpg"
+	}
+
+	widths := append([]int(nil), p.Widths...)
+	sort.Ints(widths)
+	for i, width := range widths {
+		if width <= 0 || width >= result.Width || (i > 0 && width == widths[i-1]) {
+			continue
+		}
+		resized := imaging.Resize(img, width, 0, imaging.Lanczos)
+		encoded, err := p.encode(resized, format)
+		if err != nil {
+			return nil, err
+		}
+		name := strconv.Itoa(width) + "w"
+		variant := Variant{Name: name + "." + extension, Width: width, Height: resized.Bounds().Dy(), ContentType: format, Data: encoded}
+		result.Variants = append(result.Variants, variant)
+
+		if webp, ok, err := p.smallerWebP(resized, len(encoded)); err != nil {
+			return nil, err
+		} else if ok {
+			result.Variants = append(result.Variants, Variant{Name: name + ".webp", Width: variant.Width, Height: variant.Height, ContentType: "image/webp", Data: webp})
+		}
+	}
+
+	if contentType != "image/webp" {
+		if webp, ok, err := p.smallerWebP(img, len(original)); err != nil {
+			return nil, err
+		} else if ok {
+			result.Variants = append(result.Variants, Variant{Name: "full.webp", Width: result.Width, Height: result.Height, ContentType: "image/webp", Data: webp})
+		}
+	}
+	return result, nil
+}
+
+// Sanitize removes the metadata of the image and turns JPEG photos upright, so the file can be
+// published as is. Only turned photos are decoded and re-encoded; those too large to decode
+// still have their metadata removed.
+func (p Processor) Sanitize(data []byte, contentType string) ([]byte, error) {
+	if !Supported(contentType) {
+		return nil, ErrUnsupported
+	}
+
+	if contentType == "image/jpeg" {
+		if orientation := Orientation(data); orientation != 1 {
+			config, _, err := image.DecodeConfig(bytes.NewReader(data))
+			if err != nil {
+				return nil, fmt.Errorf("imageprocessing: reading image header: %w", err)
+			}
+			if p.MaxPixels <= 0 || config.Width*config.Height <= p.MaxPixels {
+				img, _, err := image.Decode(bytes.NewReader(data))
+				if err != nil {
+					return nil, fmt.Errorf("imageprocessing: decoding image: %w", err)
+				}
+				return p.encode(orient(img, orientation), "image/jpeg")
+			}
+		}
+	}
+
+	stripped, _ := StripMetadata(data, contentType)
+	return stripped, nil
+}
+
+// Supported reports whether files of the content type can be processed
+func Supported(contentType string) bool {
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif", "image/webp":
+		return true
+	}
+	return false
+}
+
+func (p Processor) encode(img image.Image, contentType string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch contentType {
+	case "image/jpeg":
+		quality := p.JPEGQuality
+		if quality < 1 || quality > 100 {
+			quality = jpeg.DefaultQuality
+		}
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+	case "image/png":
+		err = png.Encode(&buf, img)
+	case "image/webp":
+		err = nativewebp.Encode(&buf, img, nil)
+	default:
+		err = ErrUnsupported
+	}
+	if err != nil {
+		return nil, fmt.Errorf("imageprocessing: encoding %s: %w", contentType, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// smallerWebP encodes the image as WebP and reports whether that beat the given size
+func (p Processor) smallerWebP(img image.Image, size int) ([]byte, bool, error) {
+	webp, err := p.encode(img, "image/webp")
+	if err != nil {
+		return nil, false, err
+	}
+	return webp, len(webp) < size, nil
+}
+
+// orient applies an EXIF orientation so the image is displayed upright
+func orient(img image.Image, orientation int) image.Image {
+	switch orientation {
+	case 2:
+		return imaging.FlipH(img)
+	case 3:
+		return imaging.Rotate180(img)
+	case 4:
+		return imaging.FlipV(img)
+	case 5:
+		return imaging.Transpose(img)
+	case 6:
+		return imaging.Rotate270(img)
+	case 7:
+		return imaging.Transverse(img)
+	case 8:
+		return imaging.Rotate90(img)
+	}
+	return img
+}
@@ -0,0 +1,154 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// canonical re-encodes a JSON document so equal documents compare equal as strings
+func canonical(t *testing.T, doc string) string {
+	t.Helper()
+	var value any
+	if err := json.Unmarshal([]byte(doc), &value); err != nil {
+		t.Fatalf("invalid JSON %s: %v", doc, err)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{"add member", `{"a":1}`, `[{"op":"add","path":"/b","value":2}]`, `{"a":1,"b":2}`},
+		{"add replaces existing member", `{"a":1}`, `[{"op":"add","path":"/a","value":[1]}]`, `{"a":[1]}`},
+		{"add nested", `{"a":{"b":1}}`, `[{"op":"add","path":"/a/c","value":null}]`, `{"a":{"b":1,"c":null}}`},
+		{"add inserts into array", `{"a":[1,3]}`, `[{"op":"add","path":"/a/1","value":2}]`, `{"a":[1,2,3]}`},
+		{"add at array end index", `{"a":[1]}`, `[{"op":"add","path":"/a/1","value":2}]`, `{"a":[1,2]}`},
+		{"add appends with dash", `{"a":[1,2]}`, `[{"op":"add","path":"/a/-","value":3}]`, `{"a":[1,2,3]}`},
+		{"add whole document", `{"a":1}`, `[{"op":"add","path":"","value":{"b":2}}]`, `{"b":2}`},
+		{"remove member", `{"a":1,"b":2}`, `[{"op":"remove","path":"/a"}]`, `{"b":2}`},
+		{"remove array element", `{"a":[1,2,3]}`, `[{"op":"remove","path":"/a/1"}]`, `{"a":[1,3]}`},
+		{"replace member", `{"a":1}`, `[{"op":"replace","path":"/a","value":"x"}]`, `{"a":"x"}`},
+		{"replace array element", `{"a":[1,2,3]}`, `[{"op":"replace","path":"/a/2","value":4}]`, `{"a":[1,2,4]}`},
+		{"replace whole document", `{"a":1}`, `[{"op":"replace","path":"","value":[1]}]`, `[1]`},
+		{"move member", `{"a":{"b":1},"c":{}}`, `[{"op":"move","from":"/a/b","path":"/c/d"}]`, `{"a":{},"c":{"d":1}}`},
+		{"move array element", `{"a":[1,2,3]}`, `[{"op":"move","from":"/a/0","path":"/a/-"}]`, `{"a":[2,3,1]}`},
+		{"copy member", `{"a":{"b":1}}`, `[{"op":"copy","from":"/a","path":"/c"}]`, `{"a":{"b":1},"c":{"b":1}}`},
+		{"copy is independent", `{"a":{"b":1}}`, `[{"op":"copy","from":"/a","path":"/c"},{"op":"add","path":"/c/b","value":2}]`, `{"a":{"b":1},"c":{"b":2}}`},
+		{"test passes", `{"a":[1,{"b":"x"}]}`, `[{"op":"test","path":"/a","value":[1,{"b":"x"}]}]`, `{"a":[1,{"b":"x"}]}`},
+		{"test compares numbers by value", `{"a":1}`, `[{"op":"test","path":"/a","value":1.0}]`, `{"a":1}`},
+		{"escaped slash", `{"a/b":1}`, `[{"op":"replace","path":"/a~1b","value":2}]`, `{"a/b":2}`},
+		{"escaped tilde", `{"a~b":1}`, `[{"op":"remove","path":"/a~0b"}]`, `{}`},
+		{"tilde escape decoded once", `{"~1":1}`, `[{"op":"add","path":"/~01","value":2}]`, `{"~1":2}`},
+		{"operations run in order", `{}`, `[{"op":"add","path":"/a","value":1},{"op":"move","from":"/a","path":"/b"},{"op":"test","path":"/b","value":1}]`, `{"b":1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply([]byte(tt.doc), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if want := canonical(t, tt.want); string(got) != want {
+				t.Errorf("Apply() = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		patch   string
+		wantErr string
+	}{
+		{"patch not an array", `{}`, `{"op":"add"}`, "JSON array"},
+		{"unknown operation", `{}`, `[{"op":"merge","path":"/a"}]`, "unknown operation"},
+		{"missing path", `{}`, `[{"op":"remove"}]`, "missing path"},
+		{"missing value", `{}`, `[{"op":"add","path":"/a"}]`, "missing value"},
+		{"missing from", `{}`, `[{"op":"copy","path":"/a"}]`, "missing from"},
+		{"invalid pointer", `{}`, `[{"op":"add","path":"a","value":1}]`, "invalid JSON pointer"},
+		{"add to missing parent", `{}`, `[{"op":"add","path":"/a/b","value":1}]`, "path does not exist"},
+		{"add past array end", `{"a":[1]}`, `[{"op":"add","path":"/a/2","value":1}]`, "out of range"},
+		{"remove missing member", `{}`, `[{"op":"remove","path":"/a"}]`, "path does not exist"},
+		{"remove out of range", `{"a":[1]}`, `[{"op":"remove","path":"/a/1"}]`, "out of range"},
+		{"remove dash index", `{"a":[1]}`, `[{"op":"remove","path":"/a/-"}]`, "invalid array index"},
+		{"remove whole document", `{}`, `[{"op":"remove","path":""}]`, "whole document"},
+		{"replace missing member", `{}`, `[{"op":"replace","path":"/a","value":1}]`, "path does not exist"},
+		{"leading zero index", `{"a":[1,2]}`, `[{"op":"replace","path":"/a/01","value":1}]`, "invalid array index"},
+		{"negative index", `{"a":[1]}`, `[{"op":"remove","path":"/a/-1"}]`, "invalid array index"},
+		{"move into own child", `{"a":{"b":{}}}`, `[{"op":"move","from":"/a","path":"/a/b/c"}]`, "its children"},
+		{"copy missing source", `{}`, `[{"op":"copy","from":"/a","path":"/b"}]`, "path does not exist"},
+		{"test fails", `{"a":1}`, `[{"op":"test","path":"/a","value":2}]`, "test failed"},
+		{"test missing path", `{}`, `[{"op":"test","path":"/a","value":1}]`, "path does not exist"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply([]byte(tt.doc), []byte(tt.patch))
+			if err == nil {
+				t.Fatalf("Apply() = %s, want error containing %q", got, tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Apply() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplyFailingTestAbortsPatch(t *testing.T) {
+	doc := []byte(`{"title":"old","tags":["a"]}`)
+	patch := []byte(`[
+		{"op":"replace","path":"/title","value":"new"},
+		{"op":"add","path":"/tags/-","value":"b"},
+		{"op":"test","path":"/title","value":"old"}
+	]`)
+
+	got, err := Apply(doc, patch)
+	if err == nil {
+		t.Fatalf("Apply() = %s, want error", got)
+	}
+	if !strings.Contains(err.Error(), "operation 2 (test)") {
+		t.Errorf("Apply() error = %v, want it to name the failing operation", err)
+	}
+	if got != nil {
+		t.Errorf("Apply() = %s, want no document", got)
+	}
+	if string(doc) != `{"title":"old","tags":["a"]}` {
+		t.Errorf("Apply() changed its input to %s", doc)
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{"replace member", `{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"remove member with null", `{"a":"b","c":1}`, `{"a":null}`, `{"c":1}`},
+		{"merge nested objects", `{"a":{"b":1,"c":2}}`, `{"a":{"c":null,"d":3}}`, `{"a":{"b":1,"d":3}}`},
+		{"arrays are replaced", `{"a":[1,2]}`, `{"a":[3]}`, `{"a":[3]}`},
+		{"object replaces scalar", `{"a":1}`, `{"a":{"b":null,"c":2}}`, `{"a":{"c":2}}`},
+		{"non-object patch replaces document", `{"a":1}`, `["x"]`, `["x"]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MergePatch([]byte(tt.doc), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("MergePatch() error = %v", err)
+			}
+			if want := canonical(t, tt.want); string(got) != want {
+				t.Errorf("MergePatch() = %s, want %s", got, want)
+			}
+		})
+	}
+}
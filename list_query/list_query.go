@@ -0,0 +1,235 @@
+package listquery
+
+import (
+	"crud_api/errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind is the type of value a query parameter accepts
+type Kind int
+
+const (
+	Text     Kind = iota // any string, trimmed
+	Int                  // positive integer
+	IntList              // comma separated positive integers, e.g. 1,2,3; may be repeated
+	Bool                 // true/false/1/0
+	DateTime             // RFC 3339 timestamp or YYYY-MM-DD (midnight UTC)
+)
+
+// Spec whitelists the query parameters of a listing endpoint. Anything not listed is rejected.
+type Spec struct {
+	Params  map[string]Kind     // accepted parameters besides sort
+	Sorts   []string            // fields that may appear in sort
+	Aliases map[string][]string // named orders accepted in place of a field list, e.g. "newest"
+}
+
+// SortField is one field of a sort, in the order it applies
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// String renders the field the way it is written in the sort parameter
+func (f SortField) String() string {
+	if f.Desc {
+		return "-" + f.Field
+	}
+	return f.Field
+}
+
+// Query holds validated query parameters
+type Query struct {
+	Sort   []SortField
+	values map[string]any
+}
+
+// Parse validates values against spec. sort is a comma separated list of fields, each optionally
+// prefixed by "-" for descending order, or one of the spec aliases.
+func Parse(values url.Values, spec Spec) (*Query, error) {
+	query := &Query{values: make(map[string]any)}
+
+	// Report unknown parameters in a stable order
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		raw := values[name]
+		if name == "sort" && (len(spec.Sorts) > 0 || len(spec.Aliases) > 0) {
+			fields, err := parseSort(strings.Join(raw, ","), spec)
+			if err != nil {
+				return nil, err
+			}
+			query.Sort = fields
+			continue
+		}
+
+		kind, ok := spec.Params[name]
+		if !ok {
+			return nil, errors.BadRequest(
+				fmt.Sprintf("Unknown query parameter '%s'; supported parameters are %s", name, supported(spec)),
+				fmt.Sprintf("Client sent unsupported query parameter '%s'", name),
+			)
+		}
+
+		value, err := parseValue(name, kind, raw)
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			query.values[name] = value
+		}
+	}
+	return query, nil
+}
+
+// Text returns a Text parameter or "" when absent
+func (q *Query) Text(name string) string {
+	value, _ := q.values[name].(string)
+	return value
+}
+
+// Int returns an Int parameter or 0 when absent
+func (q *Query) Int(name string) int {
+	value, _ := q.values[name].(int)
+	return value
+}
+
+// Ints returns an IntList parameter or nil when absent
+func (q *Query) Ints(name string) []int {
+	value, _ := q.values[name].([]int)
+	return value
+}
+
+// Bool returns a Bool parameter or false when absent
+func (q *Query) Bool(name string) bool {
+	value, _ := q.values[name].(bool)
+	return value
+}
+
+// Time returns a DateTime parameter or nil when absent
+func (q *Query) Time(name string) *time.Time {
+	value, ok := q.values[name].(time.Time)
+	if !ok {
+		return nil
+	}
+	return &value
+}
+
+// Has reports whether the parameter was given with a non-empty value
+func (q *Query) Has(name string) bool {
+	_, ok := q.values[name]
+	return ok
+}
+
+func parseValue(name string, kind Kind, raw []string) (any, error) {
+	if kind != IntList && len(raw) > 1 {
+		return nil, invalid(name, "may only be given once", strings.Join(raw, ","))
+	}
+	value := strings.TrimSpace(strings.Join(raw, ","))
+	if value == "" {
+		return nil, nil
+	}
+
+	switch kind {
+	case Int:
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			return nil, invalid(name, "must be a positive integer", value)
+		}
+		return parsed, nil
+	case IntList:
+		var ids []int
+		for _, item := range strings.Split(value, ",") {
+			parsed, err := strconv.Atoi(strings.TrimSpace(item))
+			if err != nil || parsed <= 0 {
+				return nil, invalid(name, "must be a comma separated list of positive integers", value)
+			}
+			if !slices.Contains(ids, parsed) {
+				ids = append(ids, parsed)
+			}
+		}
+		return ids, nil
+	case Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, invalid(name, "must be true or false", value)
+		}
+		return parsed, nil
+	case DateTime:
+		if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+			return parsed, nil
+		}
+		parsed, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			return nil, invalid(name, "must be a date (YYYY-MM-DD) or an RFC 3339 timestamp", value)
+		}
+		return parsed, nil
+	}
+	return value, nil
+}
+
+func parseSort(value string, spec Spec) ([]SortField, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	if alias, ok := spec.Aliases[value]; ok {
+		value = strings.Join(alias, ",")
+	}
+
+	var fields []SortField
+	seen := make(map[string]bool)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		field := SortField{Field: strings.TrimPrefix(item, "-"), Desc: strings.HasPrefix(item, "-")}
+		if !slices.Contains(spec.Sorts, field.Field) {
+			return nil, errors.BadRequest(
+				fmt.Sprintf("Cannot sort by '%s'; sortable fields are %s", item, sortable(spec)),
+				fmt.Sprintf("Client sent unsupported sort field '%s'", item),
+			)
+		}
+		if seen[field.Field] {
+			return nil, invalid("sort", "lists '"+field.Field+"' more than once", value)
+		}
+		seen[field.Field] = true
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+func invalid(name, reason, value string) error {
+	return errors.BadRequest(
+		fmt.Sprintf("Invalid value for '%s': %s", name, reason),
+		fmt.Sprintf("Client sent invalid %s=%q", name, value),
+	)
+}
+
+func supported(spec Spec) string {
+	names := make([]string, 0, len(spec.Params)+1)
+	for name := range spec.Params {
+		names = append(names, name)
+	}
+	if len(spec.Sorts) > 0 || len(spec.Aliases) > 0 {
+		names = append(names, "sort")
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func sortable(spec Spec) string {
+	names := slices.Clone(spec.Sorts)
+	for alias := range spec.Aliases {
+		names = append(names, alias)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ") + " (prefix a field with - for descending order)"
+}
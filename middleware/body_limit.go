@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	responsemodels "crud_api/response_models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// BodyLimit rejects request bodies larger than limit bytes with 413. Bodies without a
+// Content-Length are cut off once they exceed the limit.
+func BodyLimit(limit int64) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if req.ContentLength > limit {
+				return responsemodels.ErrorResponse(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body may be at most %d bytes", limit))
+			}
+			req.Body = http.MaxBytesReader(c.Response(), req.Body, limit)
+			return next(c)
+		}
+	}
+}
+
+// NoSniff stops browsers from guessing a different content type than the one served,
+// which matters for user uploaded files
+func NoSniff(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		c.Response().Header().Set(echo.HeaderXContentTypeOptions, "nosniff")
+		return next(c)
+	}
+}
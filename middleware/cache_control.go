@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// CacheControl applies a Cache-Control policy (e.g. "public, max-age=60") to successful
+// responses of a route. Requests with an Authorization header can receive per-user content,
+// so their responses are only cacheable privately; other statuses are never stored.
+func CacheControl(policy string) echo.MiddlewareFunc {
+	private := privatePolicy(policy)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			res := c.Response()
+			authorized := c.Request().Header.Get(echo.HeaderAuthorization) != ""
+
+			res.Header().Add(echo.HeaderVary, echo.HeaderAuthorization)
+			res.Before(func() {
+				switch {
+				case res.Status != http.StatusOK && res.Status != http.StatusNotModified:
+					res.Header().Set(echo.HeaderCacheControl, "no-store")
+				case authorized:
+					res.Header().Set(echo.HeaderCacheControl, private)
+				default:
+					res.Header().Set(echo.HeaderCacheControl, policy)
+				}
+			})
+			return next(c)
+		}
+	}
+}
+
+// privatePolicy turns a shared cache policy into one only the client may apply
+func privatePolicy(policy string) string {
+	directives := []string{"private"}
+	for _, directive := range strings.Split(policy, ",") {
+		directive = strings.TrimSpace(directive)
+		name := strings.ToLower(strings.SplitN(directive, "=", 2)[0])
+		if directive == "" || name == "public" || name == "private" || name == "s-maxage" || name == "proxy-revalidate" {
+			continue
+		}
+		directives = append(directives, directive)
+	}
+	return strings.Join(directives, ", ")
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"crud_api/models"
 	responsemodels "crud_api/response_models"
 	"crud_api/services"
 
@@ -30,55 +31,84 @@ func (config *JWTMiddlewareConfig) Middleware(next echo.HandlerFunc) echo.Handle
 			return responsemodels.ErrorResponse(c, http.StatusUnauthorized, "Missing authorization header")
 		}
 
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			return responsemodels.ErrorResponse(c, http.StatusUnauthorized, "Invalid authorization header format")
+		user, message := config.authenticate(authHeader)
+		if user == nil {
+			return responsemodels.ErrorResponse(c, http.StatusUnauthorized, message)
 		}
-		tokenString := parts[1]
 
-		jwtSecret := []byte(os.Getenv("JWT_SECRET"))
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, echo.NewHTTPError(http.StatusUnauthorized, "Unexpected signing method")
-			}
-			return jwtSecret, nil
-		})
+		c.Set("user", *user)
+		return next(c)
+	}
+}
 
-		if err != nil {
-			return responsemodels.ErrorResponse(c, http.StatusUnauthorized, "Invalid or expired token")
+// OptionalMiddleware sets the user in the context when a valid token is sent, but lets
+// anonymous requests (and requests with unusable tokens) through to public endpoints
+func (config *JWTMiddlewareConfig) OptionalMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		authHeader := c.Request().Header.Get("Authorization")
+		if authHeader == "" {
+			return next(c)
 		}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok || !token.Valid {
-			return responsemodels.ErrorResponse(c, http.StatusUnauthorized, "Invalid token claims")
+		if user, _ := config.authenticate(authHeader); user != nil {
+			c.Set("user", *user)
 		}
+		return next(c)
+	}
+}
 
-		if exp, ok := claims["exp"].(float64); ok {
-			if time.Now().Unix() > int64(exp) {
-				return responsemodels.ErrorResponse(c, http.StatusUnauthorized, "Token has expired")
-			}
+// authenticate validates the bearer token and loads its user. On failure the user is nil
+// and the message explains why the token was rejected.
+func (config *JWTMiddlewareConfig) authenticate(authHeader string) (*models.User, string) {
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return nil, "Invalid authorization header format"
+	}
+	tokenString := parts[1]
+
+	jwtSecret := []byte(os.Getenv("JWT_SECRET"))
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, echo.NewHTTPError(http.StatusUnauthorized, "Unexpected signing method")
 		}
+		return jwtSecret, nil
+	})
+
+	if err != nil {
+		return nil, "Invalid or expired token"
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, "Invalid token claims"
+	}
 
-		var userID uint
-		switch v := claims["user_id"].(type) {
-		case float64:
-			userID = uint(v)
-		case string:
-			id, err := strconv.ParseUint(v, 10, 64)
-			if err != nil {
-				return responsemodels.ErrorResponse(c, http.StatusUnauthorized, "Invalid user ID format")
-			}
-			userID = uint(id)
-		default:
-			return responsemodels.ErrorResponse(c, http.StatusUnauthorized, "Invalid user ID type")
+	if exp, ok := claims["exp"].(float64); ok {
+		if time.Now().Unix() > int64(exp) {
+			return nil, "Token has expired"
 		}
+	}
 
-		user, err := config.UserService.GetByID(userID)
+	var userID uint
+	switch v := claims["user_id"].(type) {
+	case float64:
+		userID = uint(v)
+	case string:
+		id, err := strconv.ParseUint(v, 10, 64)
 		if err != nil {
-			return responsemodels.ErrorResponse(c, http.StatusUnauthorized, "User not found")
+			return nil, "Invalid user ID format"
 		}
+		userID = uint(id)
+	default:
+		return nil, "Invalid user ID type"
+	}
 
-		c.Set("user", *user)
-		return next(c)
+	user, err := config.UserService.GetByID(userID)
+	if err != nil {
+		return nil, "User not found"
+	}
+	if user.Disabled {
+		return nil, "Account is disabled"
 	}
+	return user, ""
 }
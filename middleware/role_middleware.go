@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+
+	"crud_api/models"
+	responsemodels "crud_api/response_models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RequireRole only lets authenticated users with one of the given roles through.
+// It must run after JWTMiddlewareConfig.Middleware, which stores the user in the context.
+func RequireRole(roles ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			user, ok := c.Get("user").(models.User)
+			if !ok {
+				return responsemodels.ErrorResponse(c, http.StatusUnauthorized, "Missing authenticated user")
+			}
+
+			for _, role := range roles {
+				if user.Role == role {
+					return next(c)
+				}
+			}
+			return responsemodels.ErrorResponse(c, http.StatusForbidden, "You are not allowed to access this resource")
+		}
+	}
+}
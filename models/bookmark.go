@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Bookmark is hard-deleted when removed, so it has no DeletedAt and the
+// unique (user, post) index stays meaningful
+type Bookmark struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_bookmarks_user_post"`
+	User      User      `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	PostID    uint      `json:"post_id" gorm:"not null;uniqueIndex:idx_bookmarks_user_post"`
+	Post      Post      `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE"`
+	Folder    string    `json:"folder" gorm:"size:100;index"`
+	Note      string    `json:"note" gorm:"type:text"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
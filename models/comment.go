@@ -0,0 +1,25 @@
+package models
+
+import "gorm.io/gorm"
+
+const (
+	CommentStatusPending  = "pending"
+	CommentStatusApproved = "approved"
+	CommentStatusSpam     = "spam"
+	CommentStatusRejected = "rejected"
+	CommentStatusDeleted  = "deleted" // placeholder kept so replies stay attached to their thread
+)
+
+type Comment struct {
+	gorm.Model
+	PostID   uint   `json:"post_id" gorm:"index;not null"`
+	Post     Post   `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE"`
+	AuthorID uint   `json:"author_id" gorm:"index;not null"`
+	Author   User   `gorm:"foreignKey:AuthorID;constraint:OnDelete:CASCADE"`
+	ParentID *uint  `json:"parent_id" gorm:"index"`
+	RootID   *uint  `json:"root_id" gorm:"index"` // top-level ancestor, used to load whole threads at once
+	Body     string `json:"body" gorm:"type:text;not null"`
+	Status   string `json:"status" gorm:"size:20;index;default:pending"`
+
+	Replies []Comment `json:"replies" gorm:"-"` // filled in when building threads
+}
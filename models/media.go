@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+const (
+	MediaStatusProcessing = "processing"
+	MediaStatusReady      = "ready"
+	MediaStatusFailed     = "failed"
+)
+
+// Media is an uploaded file. The file itself lives in the configured storage under
+// StorageKey; deleting the row removes it from the posts it was attached to.
+type Media struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	OwnerID     uint      `json:"owner_id" gorm:"not null;index"`
+	Owner       User      `json:"-" gorm:"foreignKey:OwnerID;constraint:OnDelete:CASCADE"`
+	StorageKey  string    `json:"storage_key" gorm:"size:255;not null;uniqueIndex"`
+	URL         string    `json:"url" gorm:"size:1024;not null"`
+	Filename    string    `json:"filename" gorm:"size:255;not null"`
+	ContentType string    `json:"content_type" gorm:"size:100;not null"`
+	Size        int64     `json:"size" gorm:"not null"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	// Images are processed in the background after the upload: until they are ready the
+	// original may still carry its metadata and there are no variants
+	Status   string         `json:"status" gorm:"size:20;not null;default:ready;index"`
+	Width    int            `json:"width"`
+	Height   int            `json:"height"`
+	Variants []MediaVariant `json:"variants" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// MediaVariant is a resized or converted rendition of an uploaded image
+type MediaVariant struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	MediaID     uint   `json:"media_id" gorm:"not null;uniqueIndex:idx_media_variant_name"`
+	Name        string `json:"name" gorm:"size:50;not null;uniqueIndex:idx_media_variant_name"`
+	StorageKey  string `json:"storage_key" gorm:"size:255;not null;uniqueIndex"`
+	URL         string `json:"url" gorm:"size:1024;not null"`
+	ContentType string `json:"content_type" gorm:"size:100;not null"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	Size        int64  `json:"size" gorm:"not null"`
+}
@@ -2,12 +2,116 @@ package models
 
 import "gorm.io/gorm"
 
+const (
+	PostStatusDraft     = "draft"
+	PostStatusPublished = "published"
+	PostStatusArchived  = "archived"
+)
+
+// PostStatuses lists the states a post can be in; only published posts are listed publicly
+var PostStatuses = []string{PostStatusDraft, PostStatusPublished, PostStatusArchived}
+
+// IsPostStatus reports whether s is one of PostStatuses
+func IsPostStatus(s string) bool {
+	for _, known := range PostStatuses {
+		if s == known {
+			return true
+		}
+	}
+	return false
+}
+
 type Post struct {
 	gorm.Model
 	Title       string   `json:"title"`
 	Description string   `json:"description"`
 	AuthorID    uint     `json:"author_id"`
 	Author      User     `gorm:"foreignKey:AuthorID;constraint:OnDelete:CASCADE"`
-	CategoryID  uint     `json:"category_id" gorm:"default:6"`
+	CategoryID  uint     `json:"category_id" gorm:"default:6;index"`
 	Category    Category `gorm:"foreignKey:CategoryID;constraint:OnDelete:SET NULL"`
+
+	CommentsLocked bool `json:"comments_locked" gorm:"not null;default:false"`
+
+	// Users other than the author with a role on the post, including pending invitations
+	Collaborators []PostCollaborator `json:"collaborators" gorm:"constraint:OnDelete:CASCADE"`
+
+	Status string `json:"status" gorm:"size:20;not null;default:published;index"`
+	Tags   []Tag  `json:"tags" gorm:"many2many:post_tags;constraint:OnDelete:CASCADE"`
+
+	// Language of the post. Posts sharing a translation group are versions of the same post in
+	// different languages; the group is identified by the ID of the post it was started from.
+	Locale             string `json:"locale" gorm:"size:10;not null;default:en;index"`
+	TranslationGroupID *uint  `json:"translation_group_id" gorm:"index"`
+
+	// Uploaded files shown with the post, in upload order
+	Media []Media `json:"media" gorm:"many2many:post_media;constraint:OnDelete:CASCADE"`
+
+	// Part of a series, numbered from 1 in reading order
+	SeriesID       *uint   `json:"series_id" gorm:"index"`
+	Series         *Series `json:"-" gorm:"foreignKey:SeriesID;constraint:OnDelete:SET NULL"`
+	SeriesPosition int     `json:"series_position" gorm:"not null;default:0"`
+
+	FeaturedMediaID *uint  `json:"featured_media_id"`
+	FeaturedMedia   *Media `json:"featured_media" gorm:"foreignKey:FeaturedMediaID;constraint:OnDelete:SET NULL"`
+
+	// Excerpt is the summary written by the author; AutoExcerpt is generated from the
+	// description on every save and used when the author didn't write one
+	Excerpt     string `json:"excerpt" gorm:"size:500"`
+	AutoExcerpt string `json:"auto_excerpt" gorm:"size:500"`
+
+	// Computed from the description on every save
+	WordCount   int `json:"word_count" gorm:"not null;default:0"`
+	ReadingTime int `json:"reading_time" gorm:"not null;default:0"` // minutes
+
+	// Search engine and social preview metadata; empty fields fall back to the post content
+	MetaTitle       string `json:"meta_title" gorm:"size:100"`
+	MetaDescription string `json:"meta_description" gorm:"size:300"`
+	CanonicalURL    string `json:"canonical_url" gorm:"size:2048"`
+	OGTitle         string `json:"og_title" gorm:"size:100"`
+	OGDescription   string `json:"og_description" gorm:"size:300"`
+	TwitterCard     string `json:"twitter_card" gorm:"size:30"`
+
+	// Incremented on every edit; updates only apply to the version they were based on
+	Version uint `json:"version" gorm:"not null;default:1"`
+
+	// Aggregates filled in by the repository through subqueries; never written back
+	CommentCount  int64 `json:"comment_count" gorm:"->;-:migration"`
+	ReactionCount int64 `json:"reaction_count" gorm:"->;-:migration"`
+
+	// Only filled in when the listing was filtered by a full-text search. The highlights mark
+	// matches with <mark> tags in the raw title and description text.
+	SearchRank     float64 `json:"search_rank" gorm:"->;-:migration"`
+	TitleHighlight string  `json:"title_highlight" gorm:"->;-:migration"`
+	Snippet        string  `json:"snippet" gorm:"->;-:migration"`
+
+	// Filled in by ReactionService.Annotate
+	ReactionCounts  map[string]int64 `json:"reactions" gorm:"-"`
+	ViewerReactions []string         `json:"viewer_reactions" gorm:"-"`
+
+	// Filled in by SeriesService.Annotate
+	SeriesNav *SeriesNav `json:"series_nav" gorm:"-"`
+
+	// Filled in by PostService.AnnotateTranslations
+	Translations []PostTranslation `json:"translations" gorm:"-"`
+}
+
+// PostTranslation is a published version of a post in another language
+type PostTranslation struct {
+	ID     uint   `json:"id"`
+	Locale string `json:"locale"`
+	Title  string `json:"title"`
+}
+
+// RoleOf returns the role the user has on the post: owner for its author, the role of an
+// accepted invitation for collaborators, and "" for everyone else
+func (p Post) RoleOf(userID uint) string {
+	if p.AuthorID == userID {
+		return CollaboratorRoleOwner
+	}
+	for _, collaborator := range p.Collaborators {
+		if collaborator.UserID == userID && collaborator.Status == CollaboratorStatusAccepted {
+			return collaborator.Role
+		}
+	}
+	return ""
 }
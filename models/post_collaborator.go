@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+const (
+	CollaboratorRoleOwner    = "owner"     // may edit and delete the post and manage its collaborators
+	CollaboratorRoleCoAuthor = "co-author" // may edit the post
+	CollaboratorRoleReviewer = "reviewer"  // may read the post before it is published
+)
+
+// CollaboratorRoles lists the roles a user can be invited to on a post
+var CollaboratorRoles = []string{CollaboratorRoleOwner, CollaboratorRoleCoAuthor, CollaboratorRoleReviewer}
+
+// IsCollaboratorRole reports whether s is one of CollaboratorRoles
+func IsCollaboratorRole(s string) bool {
+	for _, known := range CollaboratorRoles {
+		if s == known {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	CollaboratorStatusInvited  = "invited"
+	CollaboratorStatusAccepted = "accepted"
+)
+
+// PostCollaborator gives a user other than the post author a role on the post. The role only
+// takes effect once the user accepts the invitation; declined invitations are deleted.
+type PostCollaborator struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	PostID      uint       `json:"post_id" gorm:"not null;uniqueIndex:idx_post_collaborators_post_user"`
+	UserID      uint       `json:"user_id" gorm:"not null;uniqueIndex:idx_post_collaborators_post_user;index"`
+	User        User       `json:"user" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Role        string     `json:"role" gorm:"size:20;not null"`
+	Status      string     `json:"status" gorm:"size:20;not null;default:invited;index"`
+	InvitedByID uint       `json:"invited_by_id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	AcceptedAt  *time.Time `json:"accepted_at"`
+
+	// Filled in by the repository when listing a user's invitations
+	PostTitle string `json:"post_title" gorm:"->;-:migration"`
+}
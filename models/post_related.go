@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// RelatedPostList tracks whether the stored related posts of a post are current. A list is
+// current once computed and until a change to the post or one of its candidates marks it stale.
+type RelatedPostList struct {
+	PostID     uint      `gorm:"primaryKey"`
+	Post       Post      `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE"`
+	ComputedAt time.Time `gorm:"not null"`
+	StaleAt    *time.Time
+}
+
+// RelatedPost is one entry of the stored related posts of a post, with its similarity score
+type RelatedPost struct {
+	PostID    uint    `gorm:"primaryKey"`
+	Post      Post    `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE"`
+	RelatedID uint    `gorm:"primaryKey;index"`
+	Related   Post    `gorm:"foreignKey:RelatedID;constraint:OnDelete:CASCADE"`
+	Score     float64 `gorm:"not null"`
+}
+
+func (RelatedPostList) TableName() string {
+	return "related_post_lists"
+}
+
+func (RelatedPost) TableName() string {
+	return "related_posts"
+}
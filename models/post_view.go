@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// PostViewVisitor remembers which (hashed) visitors already viewed a post on a given day,
+// so repeated views by the same visitor are only counted once
+type PostViewVisitor struct {
+	PostID      uint      `gorm:"primaryKey"`
+	Post        Post      `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE"`
+	Day         time.Time `gorm:"primaryKey;type:date"`
+	VisitorHash string    `gorm:"primaryKey;size:64"`
+}
+
+// PostDailyViews holds the number of unique visitors of a post per day
+type PostDailyViews struct {
+	PostID uint      `json:"post_id" gorm:"primaryKey"`
+	Post   Post      `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE"`
+	Day    time.Time `json:"day" gorm:"primaryKey;type:date"`
+	Views  int64     `json:"views" gorm:"not null;default:0"`
+}
+
+func (PostViewVisitor) TableName() string {
+	return "post_view_visitors"
+}
+
+func (PostDailyViews) TableName() string {
+	return "post_daily_views"
+}
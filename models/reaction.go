@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+const (
+	ReactionLike  = "like"
+	ReactionLove  = "love"
+	ReactionLaugh = "laugh"
+	ReactionWow   = "wow"
+	ReactionSad   = "sad"
+	ReactionAngry = "angry"
+)
+
+// ReactionTypes is the fixed set of reactions readers can leave on a post
+var ReactionTypes = []string{ReactionLike, ReactionLove, ReactionLaugh, ReactionWow, ReactionSad, ReactionAngry}
+
+// IsReactionType reports whether t is one of ReactionTypes
+func IsReactionType(t string) bool {
+	for _, known := range ReactionTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Reaction doesn't embed gorm.Model: removing a reaction deletes the row so the
+// unique (post, user, type) index keeps deduplicating reactions
+type Reaction struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	PostID    uint      `json:"post_id" gorm:"not null;uniqueIndex:idx_reactions_post_user_type"`
+	Post      Post      `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_reactions_post_user_type;index"`
+	User      User      `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Type      string    `json:"type" gorm:"size:20;not null;uniqueIndex:idx_reactions_post_user_type"`
+	CreatedAt time.Time `json:"created_at"`
+}
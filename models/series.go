@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Series groups posts of one author into an ordered, multi-part collection. The posts
+// point at the series they belong to; deleting the series leaves the posts in place.
+type Series struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Title       string    `json:"title" gorm:"size:200;not null"`
+	Description string    `json:"description" gorm:"type:text"`
+	OwnerID     uint      `json:"owner_id" gorm:"not null;index"`
+	Owner       User      `json:"-" gorm:"foreignKey:OwnerID;constraint:OnDelete:CASCADE"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// SeriesNav places a post within its series: its part number among the parts the
+// reader can see and the neighbouring published parts
+type SeriesNav struct {
+	ID       uint        `json:"id"`
+	Title    string      `json:"title"`
+	Position int         `json:"position"`
+	Total    int         `json:"total"`
+	Previous *SeriesPart `json:"previous"`
+	Next     *SeriesPart `json:"next"`
+}
+
+type SeriesPart struct {
+	ID    uint   `json:"id"`
+	Title string `json:"title"`
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// Tag labels posts across categories. Names are stored normalized (trimmed, lower case)
+// and tags are shared by all posts using them.
+type Tag struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"size:50;not null;uniqueIndex"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -2,9 +2,24 @@ package models
 
 import "gorm.io/gorm"
 
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
 type User struct {
 	gorm.Model
 	Name     string `json:"name"`
 	Email    string `json:"email"` // unique constraint at DB level
-	Password string `json:"password"`
+	Password string `json:"-"`
+	Role     string `json:"role" gorm:"size:20;not null;default:user"`
+
+	// Disabled users can neither log in nor use tokens issued before
+	Disabled bool `json:"disabled" gorm:"not null;default:false"`
+}
+
+// IsStaff reports whether the user may moderate other users' content
+func (u User) IsStaff() bool {
+	return u.Role == RoleModerator || u.Role == RoleAdmin
 }
@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"crud_api/errors"
+	"crud_api/models"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BookmarkFolder struct {
+	Folder string
+	Count  int64
+}
+
+type BookmarkRepository interface {
+	Save(bookmark *models.Bookmark) error
+	Remove(userID, postID uint) error
+	List(userID uint, folder *string, limit, offset int) ([]models.Bookmark, int64, error)
+	Folders(userID uint) ([]BookmarkFolder, error)
+}
+
+type bookmarkRepository struct {
+	db *gorm.DB
+}
+
+func NewBookmarkRepository(db *gorm.DB) BookmarkRepository {
+	return &bookmarkRepository{db}
+}
+
+func (r *bookmarkRepository) Save(bookmark *models.Bookmark) error {
+	// Bookmarking the same post again just moves it / updates the note
+	if err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "post_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"folder", "note", "updated_at"}),
+	}).Create(bookmark).Error; err != nil {
+		return errors.Internal(
+			"Unable to save bookmark",
+			"Database error while upserting bookmark",
+			err,
+		)
+	}
+	return nil
+}
+
+func (r *bookmarkRepository) Remove(userID, postID uint) error {
+	result := r.db.Where("user_id = ? AND post_id = ?", userID, postID).Delete(&models.Bookmark{})
+	if result.Error != nil {
+		return errors.Internal(
+			"Unable to remove bookmark",
+			"Database error while deleting bookmark",
+			result.Error,
+		)
+	}
+	if result.RowsAffected == 0 {
+		return errors.NotFound("Bookmark not found",
+			fmt.Sprintf("User %d has no bookmark for post %d", userID, postID))
+	}
+	return nil
+}
+
+func (r *bookmarkRepository) List(userID uint, folder *string, limit, offset int) ([]models.Bookmark, int64, error) {
+	var bookmarks []models.Bookmark
+	var total int64
+
+	query := r.db.Model(&models.Bookmark{}).Where("user_id = ?", userID)
+	if folder != nil {
+		query = query.Where("folder = ?", *folder)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, errors.Internal("Unable to retrieve bookmarks", "Database error while counting bookmarks", err)
+	}
+
+	// Soft-deleted posts are still loaded so the bookmark can be shown as unavailable
+	if err := query.
+		Preload("Post", func(db *gorm.DB) *gorm.DB { return db.Unscoped().Select(postSelect) }).
+		Preload("Post.Author").
+		Preload("Post.Collaborators").
+		Preload("Post.Category").
+		Order("created_at DESC, id DESC").
+		Limit(limit).Offset(offset).
+		Find(&bookmarks).Error; err != nil {
+		return nil, 0, errors.Internal("Unable to retrieve bookmarks", "Database error while listing bookmarks", err)
+	}
+
+	return bookmarks, total, nil
+}
+
+func (r *bookmarkRepository) Folders(userID uint) ([]BookmarkFolder, error) {
+	var folders []BookmarkFolder
+	if err := r.db.Model(&models.Bookmark{}).
+		Select("folder, COUNT(*) AS count").
+		Where("user_id = ?", userID).
+		Group("folder").
+		Order("folder ASC").
+		Scan(&folders).Error; err != nil {
+		return nil, errors.Internal("Unable to retrieve bookmark folders", "Database error while grouping bookmarks by folder", err)
+	}
+	return folders, nil
+}
@@ -0,0 +1,302 @@
+package repositories
+
+import (
+	"crud_api/cache"
+	"crud_api/models"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// postCacheGeneration is bumped on every post write. All cached post reads are keyed by the
+// generation they were loaded in, so a write invalidates them at once and a read that raced
+// with the write can only store its result under the outdated generation.
+const postCacheGeneration = "posts:gen"
+
+// cachedPostRepository serves post details and listings from a Cache. Aggregated counters
+// (comments, reactions) may lag behind by up to the TTL since those writes go elsewhere.
+// Methods that are not overridden go straight to the wrapped repository.
+type cachedPostRepository struct {
+	PostRepository
+	cache cache.Cache
+	ttl   time.Duration
+	group singleflight.Group
+	stats *cache.Stats
+}
+
+// cachedPostPage is how a PostPage is stored; cursors are kept in their encoded form
+type cachedPostPage struct {
+	Posts []models.Post `json:"posts"`
+	Next  string        `json:"next,omitempty"`
+	Prev  string        `json:"prev,omitempty"`
+	Total *int64        `json:"total,omitempty"`
+}
+
+type cachedPostList struct {
+	Posts []models.Post `json:"posts"`
+	Total int64         `json:"total"`
+}
+
+// NewCachedPostRepository wraps repo with a read-through cache. Concurrent misses for the same
+// key share one database query. A nil cache or non-positive ttl returns repo unchanged.
+func NewCachedPostRepository(repo PostRepository, c cache.Cache, ttl time.Duration) PostRepository {
+	if c == nil || ttl <= 0 {
+		return repo
+	}
+	return &cachedPostRepository{PostRepository: repo, cache: c, ttl: ttl, stats: cache.NewStats("posts")}
+}
+
+func (r *cachedPostRepository) FindByID(id uint) (*models.Post, error) {
+	var post models.Post
+	cached, err := r.load(fmt.Sprintf("post:%d", id), &post, func() (interface{}, error) {
+		return r.PostRepository.FindByID(id)
+	})
+	if !cached {
+		return r.PostRepository.FindByID(id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+func (r *cachedPostRepository) FindAll(filter PostFilter, offset, limit int) ([]models.Post, int64, error) {
+	key := listCacheKey("all", filter, offset, limit)
+
+	var list cachedPostList
+	cached, err := r.load(key, &list, func() (interface{}, error) {
+		posts, total, err := r.PostRepository.FindAll(filter, offset, limit)
+		return cachedPostList{Posts: posts, Total: total}, err
+	})
+	if !cached {
+		return r.PostRepository.FindAll(filter, offset, limit)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	return list.Posts, list.Total, nil
+}
+
+func (r *cachedPostRepository) FindPage(filter PostFilter, after, before *Cursor, limit int) (*PostPage, error) {
+	key := listCacheKey("page", filter, encodeCursor(after), encodeCursor(before), limit)
+
+	var stored cachedPostPage
+	cached, err := r.load(key, &stored, func() (interface{}, error) {
+		page, err := r.PostRepository.FindPage(filter, after, before, limit)
+		if err != nil {
+			return nil, err
+		}
+		return cachedPostPage{Posts: page.Posts, Next: encodeCursor(page.Next), Prev: encodeCursor(page.Prev), Total: page.Total}, nil
+	})
+	if !cached {
+		return r.PostRepository.FindPage(filter, after, before, limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	page := &PostPage{Posts: stored.Posts, Total: stored.Total}
+	if page.Next, err = DecodeCursor(stored.Next); err != nil {
+		return nil, err
+	}
+	if page.Prev, err = DecodeCursor(stored.Prev); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func (r *cachedPostRepository) Count(filter PostFilter) (int64, error) {
+	var total int64
+	cached, err := r.load(listCacheKey("count", filter), &total, func() (interface{}, error) {
+		return r.PostRepository.Count(filter)
+	})
+	if !cached {
+		return r.PostRepository.Count(filter)
+	}
+	return total, err
+}
+
+func (r *cachedPostRepository) FindRelated(id uint, limit int) ([]models.Post, error) {
+	var posts []models.Post
+	cached, err := r.load(fmt.Sprintf("related:%d:%d", id, limit), &posts, func() (interface{}, error) {
+		return r.PostRepository.FindRelated(id, limit)
+	})
+	if !cached {
+		return r.PostRepository.FindRelated(id, limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+// Writes invalidate even when they fail: a failed versioned update means the cached copy may be outdated
+
+func (r *cachedPostRepository) Create(post *models.Post) error {
+	defer r.invalidate()
+	return r.PostRepository.Create(post)
+}
+
+func (r *cachedPostRepository) Update(post *models.Post) error {
+	defer r.invalidate()
+	return r.PostRepository.Update(post)
+}
+
+func (r *cachedPostRepository) Delete(post *models.Post) error {
+	defer r.invalidate()
+	return r.PostRepository.Delete(post)
+}
+
+func (r *cachedPostRepository) SetCommentsLocked(id uint, locked bool) error {
+	defer r.invalidate()
+	return r.PostRepository.SetCommentsLocked(id, locked)
+}
+
+func (r *cachedPostRepository) Restore(id uint) error {
+	defer r.invalidate()
+	return r.PostRepository.Restore(id)
+}
+
+func (r *cachedPostRepository) Purge(id uint) error {
+	defer r.invalidate()
+	return r.PostRepository.Purge(id)
+}
+
+func (r *cachedPostRepository) AddTags(post *models.Post, names []string) error {
+	defer r.invalidate()
+	return r.PostRepository.AddTags(post, names)
+}
+
+func (r *cachedPostRepository) SetMedia(post *models.Post, media []models.Media) error {
+	defer r.invalidate()
+	return r.PostRepository.SetMedia(post, media)
+}
+
+func (r *cachedPostRepository) SetSeriesPosts(seriesID uint, postIDs []uint) error {
+	defer r.invalidate()
+	return r.PostRepository.SetSeriesPosts(seriesID, postIDs)
+}
+
+func (r *cachedPostRepository) SetTranslationGroup(postIDs []uint, groupID *uint) error {
+	defer r.invalidate()
+	return r.PostRepository.SetTranslationGroup(postIDs, groupID)
+}
+
+func (r *cachedPostRepository) SaveCollaborator(collaborator *models.PostCollaborator) error {
+	defer r.invalidate()
+	return r.PostRepository.SaveCollaborator(collaborator)
+}
+
+func (r *cachedPostRepository) RemoveCollaborator(collaborator *models.PostCollaborator) error {
+	defer r.invalidate()
+	return r.PostRepository.RemoveCollaborator(collaborator)
+}
+
+func (r *cachedPostRepository) UpdateTextStats(posts []models.Post) error {
+	defer r.invalidate()
+	return r.PostRepository.UpdateTextStats(posts)
+}
+
+// Transaction reads and writes through to the database: cached entries can't see uncommitted changes
+func (r *cachedPostRepository) Transaction(fn func(tx PostRepository) error) error {
+	defer r.invalidate()
+	return r.PostRepository.Transaction(fn)
+}
+
+func (r *cachedPostRepository) PurgeDeletedBefore(before time.Time) (int64, error) {
+	purged, err := r.PostRepository.PurgeDeletedBefore(before)
+	if purged > 0 {
+		r.invalidate()
+	}
+	return purged, err
+}
+
+// load decodes the value cached under key into dest, calling fetch on a miss. It returns false
+// when the cache can't be used at all, in which case the caller should query the database itself.
+// Errors from fetch are returned as is and never cached.
+func (r *cachedPostRepository) load(key string, dest interface{}, fetch func() (interface{}, error)) (bool, error) {
+	generation, err := r.generation()
+	if err != nil {
+		log.Printf("post cache: reading generation: %v", err)
+		return false, nil
+	}
+	key = "posts:" + generation + ":" + key
+
+	data, ok, err := r.cache.Get(key)
+	if err != nil {
+		log.Printf("post cache: get %s: %v", key, err)
+	}
+	if ok {
+		err := json.Unmarshal(data, dest)
+		if err == nil {
+			r.stats.Hit()
+			return true, nil
+		}
+		log.Printf("post cache: discarding undecodable entry %s: %v", key, err)
+	}
+	r.stats.Miss()
+
+	// Callers each decode their own copy: the loaded posts get annotated per viewer afterwards
+	shared, err, _ := r.group.Do(key, func() (interface{}, error) {
+		value, err := fetch()
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.cache.Set(key, data, r.ttl); err != nil {
+			log.Printf("post cache: set %s: %v", key, err)
+		}
+		return data, nil
+	})
+	if err != nil {
+		return true, err
+	}
+	return true, json.Unmarshal(shared.([]byte), dest)
+}
+
+func (r *cachedPostRepository) generation() (string, error) {
+	data, ok, err := r.cache.Get(postCacheGeneration)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "0", nil
+	}
+	if _, err := strconv.ParseInt(string(data), 10, 64); err != nil {
+		return "", fmt.Errorf("invalid generation %q", data)
+	}
+	return string(data), nil
+}
+
+func (r *cachedPostRepository) Invalidate() {
+	r.invalidate()
+}
+
+func (r *cachedPostRepository) invalidate() {
+	if _, err := r.cache.Incr(postCacheGeneration); err != nil {
+		log.Printf("WARNING: post cache: invalidation failed, entries may be stale for up to %s: %v", r.ttl, err)
+	}
+}
+
+// listCacheKey derives a fixed-length key from the listing parameters
+func listCacheKey(kind string, params ...interface{}) string {
+	data, _ := json.Marshal(params)
+	sum := sha256.Sum256(data)
+	return kind + ":" + hex.EncodeToString(sum[:16])
+}
+
+func encodeCursor(cursor *Cursor) string {
+	if cursor == nil {
+		return ""
+	}
+	return cursor.Encode()
+}
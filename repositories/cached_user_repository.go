@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"crud_api/cache"
+	"crud_api/models"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+// cachedUserRepository keeps users looked up by ID, which happens on every authenticated
+// request. Cached users carry no password hash; FindByEmail, used to log in, is not cached.
+type cachedUserRepository struct {
+	UserRepository
+	cache cache.Cache
+	ttl   time.Duration
+	stats *cache.Stats
+}
+
+// NewCachedUserRepository wraps repo with a read-through cache for FindByID. Updates evict the
+// user right away; a lookup racing with one can keep the old state for at most ttl, so keep it short.
+// A nil cache or non-positive ttl returns repo unchanged.
+func NewCachedUserRepository(repo UserRepository, c cache.Cache, ttl time.Duration) UserRepository {
+	if c == nil || ttl <= 0 {
+		return repo
+	}
+	return &cachedUserRepository{UserRepository: repo, cache: c, ttl: ttl, stats: cache.NewStats("users")}
+}
+
+func (r *cachedUserRepository) FindByID(id uint) (*models.User, error) {
+	key := userCacheKey(id)
+
+	data, ok, err := r.cache.Get(key)
+	if err != nil {
+		log.Printf("user cache: get %s: %v", key, err)
+	}
+	if ok {
+		var user models.User
+		err := json.Unmarshal(data, &user)
+		if err == nil {
+			r.stats.Hit()
+			return &user, nil
+		}
+		log.Printf("user cache: discarding undecodable entry %s: %v", key, err)
+	}
+	r.stats.Miss()
+
+	user, err := r.UserRepository.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := cache.SetJSON(r.cache, key, user, r.ttl); err != nil {
+		log.Printf("user cache: set %s: %v", key, err)
+	}
+	return user, nil
+}
+
+func (r *cachedUserRepository) UpdateRole(id uint, role string) error {
+	defer r.evict(id)
+	return r.UserRepository.UpdateRole(id, role)
+}
+
+func (r *cachedUserRepository) SetDisabled(id uint, disabled bool) error {
+	defer r.evict(id)
+	return r.UserRepository.SetDisabled(id, disabled)
+}
+
+func (r *cachedUserRepository) Delete(id uint) error {
+	defer r.evict(id)
+	return r.UserRepository.Delete(id)
+}
+
+func (r *cachedUserRepository) evict(id uint) {
+	if err := r.cache.Delete(userCacheKey(id)); err != nil {
+		log.Printf("WARNING: user cache: eviction of user %d failed, it may be stale for up to %s: %v", id, r.ttl, err)
+	}
+}
+
+func userCacheKey(id uint) string {
+	return fmt.Sprintf("user:%d", id)
+}
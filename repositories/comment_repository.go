@@ -0,0 +1,173 @@
+package repositories
+
+import (
+	"crud_api/errors"
+	"crud_api/models"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CommentRepository interface {
+	Create(comment *models.Comment) error
+	FindByID(id uint) (*models.Comment, error)
+	ListByPost(postID uint, after *Cursor, limit int) ([]models.Comment, error)
+	ListRootsByPost(postID uint, after *Cursor, limit int) ([]models.Comment, error)
+	ListByRoots(rootIDs []uint) ([]models.Comment, error)
+	HasReplies(id uint) (bool, error)
+	CountApprovedByAuthor(authorID uint) (int64, error)
+	ListByStatus(status string, limit, offset int) ([]models.Comment, int64, error)
+	UpdateStatus(ids []uint, status string) (int64, error)
+	Update(comment *models.Comment) error
+	Delete(comment *models.Comment) error
+}
+
+type commentRepository struct {
+	db *gorm.DB
+}
+
+func NewCommentRepository(db *gorm.DB) CommentRepository {
+	return &commentRepository{db}
+}
+
+// visibleStatuses are shown in public listings; deleted comments stay as placeholders for their replies
+var visibleStatuses = []string{models.CommentStatusApproved, models.CommentStatusDeleted}
+
+func (r *commentRepository) Create(comment *models.Comment) error {
+	if err := r.db.Create(comment).Error; err != nil {
+		return errors.Internal(
+			"Unable to create comment",
+			"Database error while creating comment",
+			err,
+		)
+	}
+	return nil
+}
+
+func (r *commentRepository) FindByID(id uint) (*models.Comment, error) {
+	var comment models.Comment
+	if err := r.db.Preload("Author").First(&comment, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NotFound("Comment not found",
+				fmt.Sprintf("Comment with id '%d' not found", id),
+			)
+		}
+		return nil, errors.Internal(
+			"Unable to find comment",
+			"Database error while searching for comment by ID",
+			err)
+	}
+	return &comment, nil
+}
+
+func (r *commentRepository) ListByPost(postID uint, after *Cursor, limit int) ([]models.Comment, error) {
+	var comments []models.Comment
+
+	query := r.db.Preload("Author").
+		Where("post_id = ? AND status IN ?", postID, visibleStatuses)
+	query = applyAscendingCursor(query, "comments", after)
+
+	if err := query.Order("created_at ASC, id ASC").Limit(limit).Find(&comments).Error; err != nil {
+		return nil, errors.Internal("Unable to retrieve comments", "Database error while listing comments", err)
+	}
+	return comments, nil
+}
+
+func (r *commentRepository) ListRootsByPost(postID uint, after *Cursor, limit int) ([]models.Comment, error) {
+	var comments []models.Comment
+
+	query := r.db.Preload("Author").
+		Where("post_id = ? AND parent_id IS NULL AND status IN ?", postID, visibleStatuses)
+	query = applyAscendingCursor(query, "comments", after)
+
+	if err := query.Order("created_at ASC, id ASC").Limit(limit).Find(&comments).Error; err != nil {
+		return nil, errors.Internal("Unable to retrieve comments", "Database error while listing comment threads", err)
+	}
+	return comments, nil
+}
+
+func (r *commentRepository) ListByRoots(rootIDs []uint) ([]models.Comment, error) {
+	var comments []models.Comment
+	if len(rootIDs) == 0 {
+		return comments, nil
+	}
+
+	if err := r.db.Preload("Author").
+		Where("root_id IN ? AND status IN ?", rootIDs, visibleStatuses).
+		Order("created_at ASC, id ASC").
+		Find(&comments).Error; err != nil {
+		return nil, errors.Internal("Unable to retrieve comments", "Database error while listing comment replies", err)
+	}
+	return comments, nil
+}
+
+func (r *commentRepository) HasReplies(id uint) (bool, error) {
+	var count int64
+	if err := r.db.Model(&models.Comment{}).Where("parent_id = ?", id).Limit(1).Count(&count).Error; err != nil {
+		return false, errors.Internal("Unable to delete comment", "Database error while checking comment replies", err)
+	}
+	return count > 0, nil
+}
+
+func (r *commentRepository) CountApprovedByAuthor(authorID uint) (int64, error) {
+	var count int64
+	if err := r.db.Model(&models.Comment{}).
+		Where("author_id = ? AND status = ?", authorID, models.CommentStatusApproved).
+		Count(&count).Error; err != nil {
+		return 0, errors.Internal("Unable to create comment", "Database error while counting approved comments", err)
+	}
+	return count, nil
+}
+
+func (r *commentRepository) ListByStatus(status string, limit, offset int) ([]models.Comment, int64, error) {
+	var comments []models.Comment
+	var total int64
+
+	query := r.db.Model(&models.Comment{}).Where("status = ?", status)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, errors.Internal("Unable to retrieve comments", "Database error while counting comments by status", err)
+	}
+
+	// Oldest first so the queue is worked through in submission order
+	if err := query.Preload("Author").Order("created_at ASC, id ASC").Limit(limit).Offset(offset).Find(&comments).Error; err != nil {
+		return nil, 0, errors.Internal("Unable to retrieve comments", "Database error while listing comments by status", err)
+	}
+	return comments, total, nil
+}
+
+func (r *commentRepository) UpdateStatus(ids []uint, status string) (int64, error) {
+	result := r.db.Model(&models.Comment{}).
+		Where("id IN ? AND status <> ?", ids, models.CommentStatusDeleted).
+		Update("status", status)
+	if result.Error != nil {
+		return 0, errors.Internal("Unable to moderate comments", "Database error while updating comment status", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+func (r *commentRepository) Update(comment *models.Comment) error {
+	if err := r.db.Omit(clause.Associations).Save(comment).Error; err != nil {
+		return errors.Internal("Unable to update comment", "Database error while updating comment", err)
+	}
+	return nil
+}
+
+func (r *commentRepository) Delete(comment *models.Comment) error {
+	if err := r.db.Delete(comment).Error; err != nil {
+		return errors.Internal("Unable to delete comment", "Database error while deleting comment", err)
+	}
+	return nil
+}
+
+// applyAscendingCursor restricts an ascending (created_at, id) listing to rows after the cursor
+func applyAscendingCursor(query *gorm.DB, table string, after *Cursor) *gorm.DB {
+	if after == nil {
+		return query
+	}
+	return query.Where(
+		fmt.Sprintf("(%s.created_at, %s.id) > (?, ?)", table, table),
+		after.CreatedAt, after.ID,
+	)
+}
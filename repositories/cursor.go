@@ -0,0 +1,114 @@
+package repositories
+
+import (
+	"crud_api/errors"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cursor marks a position in a list ordered by (created_at, id) for keyset pagination.
+// Lists ordered by other columns key the cursor on those columns' values instead, and lists
+// ordered by computed values (popularity, relevance) hold a position in the result set.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uint
+
+	Keys []string // sort column values of the row, in sort order
+
+	Offset     int
+	positional bool
+}
+
+// NewCursor builds the cursor pointing at the given row
+func NewCursor(createdAt time.Time, id uint) *Cursor {
+	return &Cursor{CreatedAt: createdAt, ID: id}
+}
+
+// NewKeyCursor builds the cursor pointing at the row with the given sort column values
+func NewKeyCursor(keys []string, id uint) *Cursor {
+	return &Cursor{Keys: keys, ID: id}
+}
+
+// NewOffsetCursor builds a cursor pointing at a position in an ordered result set
+func NewOffsetCursor(offset int) *Cursor {
+	return &Cursor{Offset: offset, positional: true}
+}
+
+// Positional reports whether the cursor holds an offset rather than a row key
+func (c Cursor) Positional() bool {
+	return c.positional
+}
+
+// Encode returns the opaque string handed out to clients
+func (c Cursor) Encode() string {
+	raw := fmt.Sprintf("%d,%d", c.CreatedAt.UnixNano(), c.ID)
+	if c.positional {
+		raw = fmt.Sprintf("@%d", c.Offset)
+	} else if c.Keys != nil {
+		keys := make([]string, 0, len(c.Keys)+1)
+		for _, key := range c.Keys {
+			keys = append(keys, url.QueryEscape(key))
+		}
+		raw = "k:" + strings.Join(append(keys, strconv.FormatUint(uint64(c.ID), 10)), ",")
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor parses a cursor produced by Encode; an empty string yields nil
+func DecodeCursor(value string) (*Cursor, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	invalid := func(err error) error {
+		return errors.BadRequest("Invalid cursor", fmt.Sprintf("Client sent malformed cursor '%s'", value), err)
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, invalid(err)
+	}
+
+	if offset, ok := strings.CutPrefix(string(raw), "@"); ok {
+		parsed, err := strconv.Atoi(offset)
+		if err != nil || parsed < 0 {
+			return nil, invalid(err)
+		}
+		return NewOffsetCursor(parsed), nil
+	}
+
+	if keyed, ok := strings.CutPrefix(string(raw), "k:"); ok {
+		parts := strings.Split(keyed, ",")
+		id, err := strconv.ParseUint(parts[len(parts)-1], 10, 64)
+		if err != nil {
+			return nil, invalid(err)
+		}
+		keys := make([]string, 0, len(parts)-1)
+		for _, part := range parts[:len(parts)-1] {
+			key, err := url.QueryUnescape(part)
+			if err != nil {
+				return nil, invalid(err)
+			}
+			keys = append(keys, key)
+		}
+		return NewKeyCursor(keys, uint(id)), nil
+	}
+
+	parts := strings.Split(string(raw), ",")
+	if len(parts) != 2 {
+		return nil, invalid(nil)
+	}
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, invalid(err)
+	}
+	id, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return nil, invalid(err)
+	}
+	return &Cursor{CreatedAt: time.Unix(0, nanos).UTC(), ID: uint(id)}, nil
+}
@@ -0,0 +1,135 @@
+package repositories
+
+import (
+	"crud_api/errors"
+	"crud_api/models"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+type MediaRepository interface {
+	Create(media *models.Media) error
+	FindByID(id uint) (*models.Media, error)
+	FindByIDs(ids []uint) ([]models.Media, error)
+	ListByOwner(ownerID uint, limit, offset int) ([]models.Media, int64, error)
+	Delete(media *models.Media) error
+	// ProcessingIDs lists the media still waiting to be processed, oldest first
+	ProcessingIDs() ([]uint, error)
+	// SaveProcessed stores the variants of processed media and its updated size, dimensions and status
+	SaveProcessed(media *models.Media, variants []models.MediaVariant) error
+	SetStatus(id uint, status string) error
+}
+
+// preloadVariants loads media variants from the smallest to the largest
+func preloadVariants(db *gorm.DB) *gorm.DB {
+	return db.Order("media_variants.width, media_variants.name")
+}
+
+type mediaRepository struct {
+	db *gorm.DB
+}
+
+func NewMediaRepository(db *gorm.DB) MediaRepository {
+	return &mediaRepository{db: db}
+}
+
+func (r *mediaRepository) Create(media *models.Media) error {
+	if err := r.db.Create(media).Error; err != nil {
+		return errors.Internal(
+			"Unable to save upload",
+			"Database error while creating media",
+			err,
+		)
+	}
+	return nil
+}
+
+func (r *mediaRepository) FindByID(id uint) (*models.Media, error) {
+	var media models.Media
+	if err := r.db.Preload("Variants", preloadVariants).First(&media, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NotFound("Media not found",
+				fmt.Sprintf("Media with id '%d' not found", id),
+			)
+		}
+		return nil, errors.Internal(
+			"Unable to find media",
+			"Database error while searching for media by ID",
+			err)
+	}
+	return &media, nil
+}
+
+// FindByIDs returns the media that exist among ids, in ID order
+func (r *mediaRepository) FindByIDs(ids []uint) ([]models.Media, error) {
+	var media []models.Media
+	if len(ids) == 0 {
+		return media, nil
+	}
+	if err := r.db.Preload("Variants", preloadVariants).Where("id IN ?", ids).Order("id").Find(&media).Error; err != nil {
+		return nil, errors.Internal("Unable to find media", "Database error while loading media by IDs", err)
+	}
+	return media, nil
+}
+
+// ListByOwner lists the user's uploads, newest first
+func (r *mediaRepository) ListByOwner(ownerID uint, limit, offset int) ([]models.Media, int64, error) {
+	var media []models.Media
+	var total int64
+
+	query := r.db.Model(&models.Media{}).Where("owner_id = ?", ownerID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, errors.Internal("Unable to retrieve media", "Database error while counting media", err)
+	}
+	if err := query.Preload("Variants", preloadVariants).Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&media).Error; err != nil {
+		return nil, 0, errors.Internal("Unable to retrieve media", "Database error while listing media", err)
+	}
+	return media, total, nil
+}
+
+func (r *mediaRepository) Delete(media *models.Media) error {
+	result := r.db.Delete(media)
+	if result.Error != nil {
+		return errors.Internal("Unable to delete media", "Database error while deleting media", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.NotFound("Media not found", fmt.Sprintf("Media with id '%d' not found for delete", media.ID))
+	}
+	return nil
+}
+
+func (r *mediaRepository) ProcessingIDs() ([]uint, error) {
+	var ids []uint
+	if err := r.db.Model(&models.Media{}).Where("status = ?", models.MediaStatusProcessing).Order("id").Pluck("id", &ids).Error; err != nil {
+		return nil, errors.Internal("Unable to retrieve media", "Database error while listing media to process", err)
+	}
+	return ids, nil
+}
+
+func (r *mediaRepository) SaveProcessed(media *models.Media, variants []models.MediaVariant) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(media).Select("size", "width", "height", "status").Updates(media)
+		if result.Error != nil {
+			return errors.Internal("Unable to save media", "Database error while updating processed media", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errors.NotFound("Media not found", fmt.Sprintf("Media with id '%d' was deleted while processing", media.ID))
+		}
+		if len(variants) == 0 {
+			return nil
+		}
+		if err := tx.Create(&variants).Error; err != nil {
+			return errors.Internal("Unable to save media", "Database error while creating media variants", err)
+		}
+		media.Variants = variants
+		return nil
+	})
+}
+
+func (r *mediaRepository) SetStatus(id uint, status string) error {
+	if err := r.db.Model(&models.Media{}).Where("id = ?", id).UpdateColumn("status", status).Error; err != nil {
+		return errors.Internal("Unable to update media", "Database error while updating media status", err)
+	}
+	return nil
+}
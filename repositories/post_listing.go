@@ -0,0 +1,348 @@
+package repositories
+
+import (
+	"crud_api/errors"
+	listquery "crud_api/list_query"
+	"crud_api/models"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Named orders accepted by post listings in place of a field list
+const (
+	PostSortNewest    = "newest"
+	PostSortPopular   = "popular"
+	PostSortRelevance = "relevance" // only valid together with a search term
+)
+
+// PostSortAliases expands the named orders into sort fields
+var PostSortAliases = map[string][]string{
+	PostSortNewest:    {"-created_at"},
+	PostSortPopular:   {"-reactions", "-created_at"},
+	PostSortRelevance: {"-relevance", "-created_at"},
+}
+
+// PostSortFields lists the fields post listings can be sorted by
+var PostSortFields = []string{"created_at", "updated_at", "title", "reactions", "comments", "relevance"}
+
+// postSortColumn describes how a sort field is ordered and, for stored columns, how it is
+// carried in a keyset cursor. Computed fields have no key and are paged by position.
+type postSortColumn struct {
+	expr string
+	key  func(post models.Post) string
+	time bool
+}
+
+var postSortColumns = map[string]postSortColumn{
+	"created_at": {expr: "posts.created_at", key: func(p models.Post) string { return p.CreatedAt.Format(time.RFC3339Nano) }, time: true},
+	"updated_at": {expr: "posts.updated_at", key: func(p models.Post) string { return p.UpdatedAt.Format(time.RFC3339Nano) }, time: true},
+	"title":      {expr: "posts.title", key: func(p models.Post) string { return p.Title }},
+	"reactions":  {expr: "reaction_count"},
+	"comments":   {expr: "comment_count"},
+	"relevance":  {expr: "search_rank"},
+}
+
+// searchSelect adds the rank and highlighted fragments of a full-text match; every placeholder
+// takes the search language followed by the query text
+const searchSelect = ", ts_rank(posts.search_vector, websearch_to_tsquery(?::regconfig, ?)) AS search_rank, " +
+	"ts_headline(?::regconfig, posts.title, websearch_to_tsquery(?::regconfig, ?), 'HighlightAll=true, StartSel=<mark>, StopSel=</mark>') AS title_highlight, " +
+	"ts_headline(?::regconfig, posts.description, websearch_to_tsquery(?::regconfig, ?), 'StartSel=<mark>, StopSel=</mark>, MinWords=15, MaxWords=35, MaxFragments=2, FragmentDelimiter=\" ... \"') AS snippet"
+
+// PostFilter narrows down and orders post listings; zero values don't filter
+type PostFilter struct {
+	Search        string
+	CategoryIDs   []int
+	AuthorIDs     []int
+	Statuses      []string
+	CreatedAfter  *time.Time // inclusive
+	CreatedBefore *time.Time // exclusive
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+
+	// When set, only posts EditorID is the author of, or an accepted collaborator with one of
+	// EditorRoles on. The author counts as an owner.
+	EditorID    uint
+	EditorRoles []string
+
+	// Preferred languages, best first. When set, only the best available translation of each
+	// post is listed; posts in none of the languages still stand in for missing translations.
+	Locales []string
+
+	// Empty means relevance when searching and newest otherwise
+	Sort []listquery.SortField
+}
+
+// PostPage is one page of a cursor paginated post listing
+type PostPage struct {
+	Posts []models.Post
+	Next  *Cursor // nil on the last page
+	Prev  *Cursor // nil on the first page
+	Total *int64  // only counted on request
+}
+
+func (r *postRepository) FindAll(filter PostFilter, offset, limit int) ([]models.Post, int64, error) {
+	var posts []models.Post
+
+	count, err := r.Count(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := r.selectPosts(r.filterPosts(filter), filter)
+	if err := query.Order(postOrder(postSort(filter), false)).Limit(limit).Offset(offset).Find(&posts).Error; err != nil {
+		return nil, 0, errors.Internal("Unable to retrieve posts", "Database error while retrieving posts", err)
+	}
+
+	return posts, count, nil
+}
+
+// FindPage returns up to limit posts following after, or preceding before, without counting the whole listing.
+// Orders on stored columns are paged by key; orders involving computed values by position.
+func (r *postRepository) FindPage(filter PostFilter, after, before *Cursor, limit int) (*PostPage, error) {
+	fields := postSort(filter)
+	for _, field := range fields {
+		if postSortColumns[field.Field].key == nil {
+			return r.findOffsetPage(filter, after, before, limit)
+		}
+	}
+
+	query := r.selectPosts(r.filterPosts(filter), filter)
+	for _, cursor := range []*Cursor{after, before} {
+		if cursor == nil {
+			continue
+		}
+		condition, args, err := keysetCondition(fields, cursor, cursor == after)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where(condition, args...)
+	}
+
+	// Fetch one extra row to know whether another page exists; going backwards walks the
+	// order in reverse and flips the rows afterwards
+	var posts []models.Post
+	if err := query.Order(postOrder(fields, before != nil)).Limit(limit + 1).Find(&posts).Error; err != nil {
+		return nil, errors.Internal("Unable to retrieve posts", "Database error while retrieving posts", err)
+	}
+
+	more := len(posts) > limit
+	if more {
+		posts = posts[:limit]
+	}
+	if before != nil {
+		slices.Reverse(posts)
+	}
+
+	page := &PostPage{Posts: posts}
+	if len(posts) == 0 {
+		return page, nil
+	}
+	first, last := posts[0], posts[len(posts)-1]
+	if more || before != nil {
+		page.Next = NewKeyCursor(postSortKeys(last, fields), last.ID)
+	}
+	if (before != nil && more) || after != nil {
+		page.Prev = NewKeyCursor(postSortKeys(first, fields), first.ID)
+	}
+	return page, nil
+}
+
+// findOffsetPage pages through orderings on computed values, where the cursors hold positions
+func (r *postRepository) findOffsetPage(filter PostFilter, after, before *Cursor, limit int) (*PostPage, error) {
+	if (after != nil && !after.Positional()) || (before != nil && !before.Positional()) {
+		return nil, errors.BadRequest("Cursor does not match the requested sort", "Client sent a keyset cursor for a computed ordering")
+	}
+
+	start, fetch := 0, limit+1
+	if after != nil {
+		start = after.Offset
+	}
+	if before != nil {
+		start = max(before.Offset-limit, 0)
+		fetch = before.Offset - start
+	}
+
+	var posts []models.Post
+	query := r.selectPosts(r.filterPosts(filter), filter)
+	if err := query.Order(postOrder(postSort(filter), false)).Limit(fetch).Offset(start).Find(&posts).Error; err != nil {
+		return nil, errors.Internal("Unable to retrieve posts", "Database error while retrieving posts", err)
+	}
+
+	page := &PostPage{}
+	if len(posts) > limit {
+		posts = posts[:limit]
+		page.Next = NewOffsetCursor(start + limit)
+	} else if before != nil {
+		page.Next = NewOffsetCursor(before.Offset)
+	}
+	if start > 0 {
+		page.Prev = NewOffsetCursor(start)
+	}
+	page.Posts = posts
+	return page, nil
+}
+
+func (r *postRepository) Count(filter PostFilter) (int64, error) {
+	var count int64
+	if err := r.filterPosts(filter).Count(&count).Error; err != nil {
+		return 0, errors.Internal("unable to count posts",
+			"Database error while counting posts", err)
+	}
+	return count, nil
+}
+
+// filterPosts applies the filter conditions shared by listings and counts
+func (r *postRepository) filterPosts(filter PostFilter) *gorm.DB {
+	query := r.db.Model(&models.Post{})
+
+	if filter.Search != "" {
+		query = query.Where("posts.search_vector @@ websearch_to_tsquery(?::regconfig, ?)", r.searchLanguage, filter.Search)
+	}
+	if len(filter.CategoryIDs) > 0 {
+		query = query.Where("posts.category_id IN ?", filter.CategoryIDs)
+	}
+	if len(filter.AuthorIDs) > 0 {
+		query = query.Where("posts.author_id IN ?", filter.AuthorIDs)
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where("posts.status IN ?", filter.Statuses)
+	}
+	if filter.CreatedAfter != nil {
+		query = query.Where("posts.created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where("posts.created_at < ?", *filter.CreatedBefore)
+	}
+	if filter.UpdatedAfter != nil {
+		query = query.Where("posts.updated_at >= ?", *filter.UpdatedAfter)
+	}
+	if filter.UpdatedBefore != nil {
+		query = query.Where("posts.updated_at < ?", *filter.UpdatedBefore)
+	}
+	if filter.EditorID != 0 {
+		collaborates := r.db.Model(&models.PostCollaborator{}).Select("1").
+			Where("post_collaborators.post_id = posts.id AND post_collaborators.user_id = ? AND post_collaborators.status = ? AND post_collaborators.role IN ?",
+				filter.EditorID, models.CollaboratorStatusAccepted, filter.EditorRoles)
+		if slices.Contains(filter.EditorRoles, models.CollaboratorRoleOwner) {
+			query = query.Where("posts.author_id = ? OR EXISTS (?)", filter.EditorID, collaborates)
+		} else {
+			query = query.Where("EXISTS (?)", collaborates)
+		}
+	}
+	if len(filter.Locales) > 0 {
+		query = query.Where("posts.id IN (?)", r.bestTranslations(filter))
+	}
+	return query
+}
+
+// bestTranslations selects, among the posts matching the rest of the filter, the one of each
+// translation group in the most preferred language. Posts outside a group are always selected.
+func (r *postRepository) bestTranslations(filter PostFilter) *gorm.DB {
+	locales := filter.Locales
+	filter.Locales = nil
+	ranked := r.filterPosts(filter).Select(
+		"posts.id, ROW_NUMBER() OVER ("+
+			"PARTITION BY posts.translation_group_id, CASE WHEN posts.translation_group_id IS NULL THEN posts.id END "+
+			"ORDER BY array_position(string_to_array(?, ','), posts.locale::text) NULLS LAST, posts.id) AS translation_rank",
+		strings.Join(locales, ","),
+	)
+	return r.db.Table("(?) AS ranked", ranked).Select("ranked.id").Where("ranked.translation_rank = 1")
+}
+
+// selectPosts loads the listed columns, adding rank and highlights when searching
+func (r *postRepository) selectPosts(query *gorm.DB, filter PostFilter) *gorm.DB {
+	query = query.Preload("Author").Preload("Collaborators", preloadCollaborators).Preload("Category").Preload("Tags", preloadTags).Preload("Media", preloadMedia).Preload("FeaturedMedia", preloadMedia)
+	if filter.Search == "" {
+		return query.Select(postSelect)
+	}
+	lang, search := r.searchLanguage, filter.Search
+	return query.Select(postSelect+searchSelect, lang, search, lang, lang, search, lang, lang, search)
+}
+
+// postSort returns the requested sort or the default one
+func postSort(filter PostFilter) []listquery.SortField {
+	if len(filter.Sort) > 0 {
+		return filter.Sort
+	}
+	if filter.Search != "" {
+		return []listquery.SortField{{Field: "relevance", Desc: true}, {Field: "created_at", Desc: true}}
+	}
+	return []listquery.SortField{{Field: "created_at", Desc: true}}
+}
+
+// postOrder builds the ORDER BY clause; posts.id breaks ties in the direction of the last field
+func postOrder(fields []listquery.SortField, reverse bool) string {
+	direction := func(desc bool) string {
+		if desc != reverse {
+			return "DESC"
+		}
+		return "ASC"
+	}
+
+	clauses := make([]string, 0, len(fields)+1)
+	for _, field := range fields {
+		clauses = append(clauses, postSortColumns[field.Field].expr+" "+direction(field.Desc))
+	}
+	clauses = append(clauses, "posts.id "+direction(fields[len(fields)-1].Desc))
+	return strings.Join(clauses, ", ")
+}
+
+// postSortKeys returns the values a keyset cursor carries for the post
+func postSortKeys(post models.Post, fields []listquery.SortField) []string {
+	keys := make([]string, 0, len(fields))
+	for _, field := range fields {
+		keys = append(keys, postSortColumns[field.Field].key(post))
+	}
+	return keys
+}
+
+// keysetCondition selects the rows after (forward) or before the cursor in the sort order:
+// (a > x) OR (a = x AND b > y) OR ... with each comparison following its field's direction
+func keysetCondition(fields []listquery.SortField, cursor *Cursor, forward bool) (string, []any, error) {
+	if cursor.Positional() || len(cursor.Keys) != len(fields) {
+		return "", nil, errors.BadRequest("Cursor does not match the requested sort", "Client sent a cursor built for another ordering")
+	}
+
+	exprs := make([]string, 0, len(fields)+1)
+	values := make([]any, 0, len(fields)+1)
+	descs := make([]bool, 0, len(fields)+1)
+	for i, field := range fields {
+		column := postSortColumns[field.Field]
+		var value any = cursor.Keys[i]
+		if column.time {
+			parsed, err := time.Parse(time.RFC3339Nano, cursor.Keys[i])
+			if err != nil {
+				return "", nil, errors.BadRequest("Invalid cursor", fmt.Sprintf("Cursor key '%s' is not a timestamp", cursor.Keys[i]), err)
+			}
+			value = parsed
+		}
+		exprs = append(exprs, column.expr)
+		values = append(values, value)
+		descs = append(descs, field.Desc)
+	}
+	exprs = append(exprs, "posts.id")
+	values = append(values, cursor.ID)
+	descs = append(descs, fields[len(fields)-1].Desc)
+
+	var alternatives []string
+	var args []any
+	for i := range exprs {
+		var terms []string
+		for j := 0; j < i; j++ {
+			terms = append(terms, exprs[j]+" = ?")
+			args = append(args, values[j])
+		}
+		operator := ">"
+		if descs[i] == forward {
+			operator = "<"
+		}
+		terms = append(terms, exprs[i]+" "+operator+" ?")
+		args = append(args, values[i])
+		alternatives = append(alternatives, "("+strings.Join(terms, " AND ")+")")
+	}
+	return "(" + strings.Join(alternatives, " OR ") + ")", args, nil
+}
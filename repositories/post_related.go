@@ -0,0 +1,97 @@
+package repositories
+
+import (
+	"crud_api/errors"
+	"crud_api/models"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// MaxRelatedPosts is how many related posts are stored per post, and so the most FindRelated returns
+const MaxRelatedPosts = 20
+
+// relatedScore rates what a post has in common with the source post: 2 for the same category,
+// 1 for each shared tag and up to 3 for the overlap of their words, measured as the Jaccard
+// index of their search lexemes
+const relatedScore = "(CASE WHEN posts.category_id = source.category_id THEN 2 ELSE 0 END) + " +
+	"(SELECT COUNT(*) FROM post_tags JOIN post_tags source_tags ON source_tags.tag_id = post_tags.tag_id " +
+	"WHERE post_tags.post_id = posts.id AND source_tags.post_id = source.id) + " +
+	"3 * COALESCE(" +
+	"cardinality(ARRAY(SELECT unnest(tsvector_to_array(posts.search_vector)) INTERSECT SELECT unnest(tsvector_to_array(source.search_vector))))::float8 / " +
+	"NULLIF(cardinality(ARRAY(SELECT unnest(tsvector_to_array(posts.search_vector)) UNION SELECT unnest(tsvector_to_array(source.search_vector)))), 0)" +
+	", 0)"
+
+// relatedCandidates narrows the posts worth scoring to those sharing the category or a tag with the
+// source post (bound twice); both lookups go through indexes, so only the words of the candidates
+// are compared
+const relatedCandidates = "SELECT candidates.id FROM posts candidates JOIN posts source ON source.category_id = candidates.category_id WHERE source.id = ? " +
+	"UNION SELECT post_tags.post_id FROM post_tags JOIN post_tags source_tags ON source_tags.tag_id = post_tags.tag_id " +
+	"WHERE source_tags.post_id = ?"
+
+// FindRelated reads the stored related posts of the post, computing them first when they are
+// missing or stale
+func (r *postRepository) FindRelated(id uint, limit int) ([]models.Post, error) {
+	var current int64
+	if err := r.db.Model(&models.RelatedPostList{}).
+		Where("post_id = ? AND (stale_at IS NULL OR stale_at < computed_at)", id).
+		Count(&current).Error; err != nil {
+		return nil, errors.Internal("Unable to retrieve related posts", fmt.Sprintf("Database error while checking related posts of post '%d'", id), err)
+	}
+	if current == 0 {
+		if err := r.refreshRelated(id); err != nil {
+			return nil, err
+		}
+	}
+
+	var posts []models.Post
+	if err := r.db.Select(postSelect).Preload("Author").Preload("Collaborators", preloadCollaborators).Preload("Category").Preload("Tags", preloadTags).Preload("Media", preloadMedia).Preload("FeaturedMedia", preloadMedia).
+		Joins("JOIN related_posts ON related_posts.related_id = posts.id AND related_posts.post_id = ?", id).
+		Where("posts.status = ?", models.PostStatusPublished).
+		Order("related_posts.score DESC, posts.created_at DESC, posts.id DESC").
+		Limit(limit).Find(&posts).Error; err != nil {
+		return nil, errors.Internal("Unable to retrieve related posts", fmt.Sprintf("Database error while loading related posts of post '%d'", id), err)
+	}
+	return posts, nil
+}
+
+// refreshRelated scores the candidates of the post and stores the best of them. The list is
+// marked computed as of the start of the transaction, so a change committed while it runs
+// still leaves it stale; its row also serializes concurrent refreshes of the same post.
+func (r *postRepository) refreshRelated(id uint) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("INSERT INTO related_post_lists (post_id, computed_at) SELECT id, now() FROM posts WHERE id = ? "+
+			"ON CONFLICT (post_id) DO UPDATE SET computed_at = EXCLUDED.computed_at", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("post_id = ?", id).Delete(&models.RelatedPost{}).Error; err != nil {
+			return err
+		}
+		// Translations of the source post say the same thing and are linked separately
+		return tx.Exec("INSERT INTO related_posts (post_id, related_id, score) "+
+			"SELECT source.id, posts.id, "+relatedScore+" FROM ("+relatedCandidates+") AS candidate "+
+			"JOIN posts ON posts.id = candidate.id JOIN posts source ON source.id = ? "+
+			"WHERE posts.id <> source.id AND posts.deleted_at IS NULL AND posts.status = ? AND posts.locale = source.locale "+
+			"AND (posts.translation_group_id IS NULL OR source.translation_group_id IS NULL OR posts.translation_group_id <> source.translation_group_id) "+
+			"ORDER BY 3 DESC, posts.created_at DESC, posts.id DESC LIMIT ?",
+			id, id, id, models.PostStatusPublished, MaxRelatedPosts).Error
+	})
+	if err != nil {
+		return errors.Internal("Unable to retrieve related posts", fmt.Sprintf("Database error while ranking posts related to post '%d'", id), err)
+	}
+	return nil
+}
+
+// markRelatedStale marks the related lists a change to the posts can affect: their own, those
+// listing them and those of the posts sharing a category or tag with them. Lists are computed
+// again when next asked for.
+func (r *postRepository) markRelatedStale(ids ...uint) error {
+	if err := r.db.Exec("UPDATE related_post_lists SET stale_at = clock_timestamp() WHERE post_id IN ? "+
+		"OR post_id IN (SELECT post_id FROM related_posts WHERE related_id IN ?) "+
+		"OR post_id IN (SELECT candidates.id FROM posts candidates JOIN posts changed ON changed.category_id = candidates.category_id WHERE changed.id IN ?) "+
+		"OR post_id IN (SELECT post_tags.post_id FROM post_tags JOIN post_tags changed_tags ON changed_tags.tag_id = post_tags.tag_id WHERE changed_tags.post_id IN ?)",
+		ids, ids, ids, ids).Error; err != nil {
+		return errors.Internal("Unable to update related posts", fmt.Sprintf("Database error while marking posts related to %v as stale", ids), err)
+	}
+	return nil
+}
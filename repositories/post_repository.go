@@ -4,25 +4,103 @@ import (
 	"crud_api/errors"
 	"crud_api/models"
 	"fmt"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PostRepository interface {
 	Create(post *models.Post) error
 	FindByID(id uint) (*models.Post, error)
-	FindAll(search, categoryID, authorID string, offset, limit int) ([]models.Post, int64, error)
+	FindAll(filter PostFilter, offset, limit int) ([]models.Post, int64, error)
+	FindPage(filter PostFilter, after, before *Cursor, limit int) (*PostPage, error)
+	Count(filter PostFilter) (int64, error)
 	Update(post *models.Post) error
 	Delete(post *models.Post) error
 	FindDuplicate(title string, authorID uint) (*models.Post, error)
+	SetCommentsLocked(id uint, locked bool) error
+	FindTrashed(userID uint, offset, limit int) ([]models.Post, int64, error)
+	FindTrashedByID(id uint) (*models.Post, error)
+	Restore(id uint) error
+	Purge(id uint) error
+	PurgeDeletedBefore(before time.Time) (int64, error)
+	AddTags(post *models.Post, names []string) error
+	// SetMedia replaces the files attached to the post
+	SetMedia(post *models.Post, media []models.Media) error
+	// FindWithoutTextStats returns posts, trashed ones included, saved before word counts were
+	// computed, in ID order after afterID. Only the ID and description are loaded.
+	FindWithoutTextStats(afterID uint, limit int) ([]models.Post, error)
+	// FindBySeries returns the posts in the series in reading order. Only the fields needed to
+	// list and navigate the series are loaded.
+	FindBySeries(seriesIDs []uint, publishedOnly bool) ([]models.Post, error)
+	// SetSeriesPosts makes postIDs, in that order, the parts of the series. Posts that were in
+	// the series before but are not listed are taken out of it. Like locking comments, this
+	// doesn't count as an edit of the posts. Listing a post of another series is a conflict.
+	SetSeriesPosts(seriesID uint, postIDs []uint) error
+	// LockSeries locks the series row until the end of the transaction, so changes to its parts
+	// are made one at a time
+	LockSeries(seriesID uint) error
+	// DeleteSeries deletes the series; its posts must have been taken out of it first. It lives
+	// here so it can share a transaction with LockSeries and SetSeriesPosts.
+	DeleteSeries(seriesID uint) error
+	// FindTranslations returns the posts in the translation groups in ID order. Only the fields
+	// needed to link the translations are loaded.
+	FindTranslations(groupIDs []uint, publishedOnly bool) ([]models.Post, error)
+	// SetTranslationGroup moves the posts into the translation group, or out of any group when
+	// groupID is nil. Like locking comments, this doesn't count as an edit of the posts.
+	SetTranslationGroup(postIDs []uint, groupID *uint) error
+	// FindRelated returns up to limit published posts in the language of the post, ranked by what
+	// they have in common with it: category, tags and words. Its translations are left out. The
+	// ranking is stored per post and recomputed once a change to the post or its candidates
+	// marks it stale.
+	FindRelated(id uint, limit int) ([]models.Post, error)
+	// FindInvitations lists the invitations the user hasn't answered yet, newest first, with the
+	// titles of the posts
+	FindInvitations(userID uint) ([]models.PostCollaborator, error)
+	// SaveCollaborator creates or updates an invitation or collaborator of a post
+	SaveCollaborator(collaborator *models.PostCollaborator) error
+	RemoveCollaborator(collaborator *models.PostCollaborator) error
+	// UpdateTextStats stores the excerpt, word count and reading time of the posts without
+	// counting as an edit
+	UpdateTextStats(posts []models.Post) error
+	// Invalidate drops cached posts after a change to what they show that was made elsewhere,
+	// such as deleting attached media. It does nothing without a cache.
+	Invalidate()
+	// Transaction runs fn with a repository bound to a single database transaction, which is
+	// committed when fn returns nil and rolled back otherwise
+	Transaction(fn func(tx PostRepository) error) error
 }
 
+// preloadTags loads the post tags in name order
+func preloadTags(db *gorm.DB) *gorm.DB {
+	return db.Order("tags.name")
+}
+
+// preloadCollaborators loads the post collaborators in the order they were invited
+func preloadCollaborators(db *gorm.DB) *gorm.DB {
+	return db.Preload("User").Order("post_collaborators.id")
+}
+
+// preloadMedia loads the post media in upload order, with their variants
+func preloadMedia(db *gorm.DB) *gorm.DB {
+	return db.Preload("Variants", preloadVariants).Order("media.id")
+}
+
+// postSelect loads the post row together with its aggregated counters
+const postSelect = "posts.*, " +
+	"(SELECT COUNT(*) FROM comments WHERE comments.post_id = posts.id AND comments.status = 'approved' AND comments.deleted_at IS NULL) AS comment_count, " +
+	"(SELECT COUNT(*) FROM reactions WHERE reactions.post_id = posts.id) AS reaction_count"
+
 type postRepository struct {
-	db *gorm.DB
+	db             *gorm.DB
+	searchLanguage string
 }
 
-func NewPostRepository(db *gorm.DB) PostRepository {
-	return &postRepository{db}
+// NewPostRepository creates a PostRepository; searchLanguage is the text search configuration
+// posts.search_vector is built with
+func NewPostRepository(db *gorm.DB, searchLanguage string) PostRepository {
+	return &postRepository{db: db, searchLanguage: searchLanguage}
 }
 
 func (r *postRepository) Create(post *models.Post) error {
@@ -33,12 +111,12 @@ func (r *postRepository) Create(post *models.Post) error {
 			err,
 		)
 	}
-	return nil
+	return r.markRelatedStale(post.ID)
 }
 
 func (r *postRepository) FindByID(id uint) (*models.Post, error) {
 	var post models.Post
-	if err := r.db.Preload("Author").Preload("Category").First(&post, id).Error; err != nil {
+	if err := r.db.Select(postSelect).Preload("Author").Preload("Collaborators", preloadCollaborators).Preload("Category").Preload("Tags", preloadTags).Preload("Media", preloadMedia).Preload("FeaturedMedia", preloadMedia).First(&post, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, errors.NotFound("Post not found",
 				fmt.Sprintf("Post with id '%d' not found", id),
@@ -52,47 +130,42 @@ func (r *postRepository) FindByID(id uint) (*models.Post, error) {
 	return &post, nil
 }
 
-func (r *postRepository) FindAll(search, categoryID, authorID string, offset, limit int) ([]models.Post, int64, error) {
-	var posts []models.Post
-	var count int64
-
-	query := r.db.Model(&models.Post{}).Preload("Author").Preload("Category")
-
-	if search != "" {
-		query = query.Where("title ILIKE ? OR description ILIKE ?", "%"+search+"%", "%"+search+"%")
-	}
-	if categoryID != "" {
-		query = query.Where("category_id = ?", categoryID)
-	}
-	if authorID != "" {
-		query = query.Where("author_id = ?", authorID)
-	}
-
-	if err := query.Count(&count).Error; err != nil {
-		return nil, 0, errors.Internal("unable to count posts",
-			"Database error while counting posts", err)
-	}
-
-	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&posts).Error; err != nil {
-		return nil, 0, errors.Internal("Unable to retrieve posts", "Database error while retrieving posts", err)
-	}
-
-	return posts, count, nil
-}
+func (r *postRepository) Invalidate() {}
 
+// Update saves the post only if it still has the version it was loaded with, and bumps the version
 func (r *postRepository) Update(post *models.Post) error {
+	loaded := post.Version
+	post.Version = loaded + 1
 
-	if err := r.db.Save(post).Error; err != nil {
-		return errors.Internal("unable to update post", "Database error while updating post", err)
+	// Comment locks, series and translation links are managed separately and must not be reverted by an edit
+	result := r.db.Model(post).Select("*").Omit("created_at", "comments_locked", "series_id", "series_position", "translation_group_id", clause.Associations).Where("version = ?", loaded).Updates(post)
+	if result.Error != nil {
+		post.Version = loaded
+		return errors.Internal("unable to update post", "Database error while updating post", result.Error)
 	}
-	return nil
+	if result.RowsAffected == 0 {
+		post.Version = loaded
+		return errors.PreconditionFailed(
+			"The post was changed by someone else; fetch it again and retry",
+			fmt.Sprintf("Post '%d' is no longer at version %d", post.ID, loaded),
+		)
+	}
+	return r.markRelatedStale(post.ID)
 }
 
+// Delete trashes the post only if it still has the version it was loaded with
 func (r *postRepository) Delete(post *models.Post) error {
-	if err := r.db.Delete(post).Error; err != nil {
-		return errors.Internal("unable to delete post", "Database error while deleting post", err)
+	result := r.db.Where("version = ?", post.Version).Delete(post)
+	if result.Error != nil {
+		return errors.Internal("unable to delete post", "Database error while deleting post", result.Error)
 	}
-	return nil
+	if result.RowsAffected == 0 {
+		return errors.PreconditionFailed(
+			"The post was changed by someone else; fetch it again and retry",
+			fmt.Sprintf("Post '%d' is no longer at version %d", post.ID, post.Version),
+		)
+	}
+	return r.markRelatedStale(post.ID)
 }
 
 func (r *postRepository) FindDuplicate(title string, authorID uint) (*models.Post, error) {
@@ -111,3 +184,270 @@ func (r *postRepository) FindDuplicate(title string, authorID uint) (*models.Pos
 	}
 	return &post, nil
 }
+
+func (r *postRepository) SetCommentsLocked(id uint, locked bool) error {
+	if err := r.db.Model(&models.Post{}).Where("id = ?", id).UpdateColumn("comments_locked", locked).Error; err != nil {
+		return errors.Internal("unable to update post", "Database error while locking post comments", err)
+	}
+	return nil
+}
+
+// FindTrashed lists the soft-deleted posts the user owns, as author or accepted owner, most
+// recently deleted first
+func (r *postRepository) FindTrashed(userID uint, offset, limit int) ([]models.Post, int64, error) {
+	var posts []models.Post
+	var count int64
+
+	owns := r.db.Model(&models.PostCollaborator{}).Select("1").
+		Where("post_collaborators.post_id = posts.id AND post_collaborators.user_id = ? AND post_collaborators.status = ? AND post_collaborators.role = ?",
+			userID, models.CollaboratorStatusAccepted, models.CollaboratorRoleOwner)
+	query := r.db.Unscoped().Model(&models.Post{}).Where("posts.deleted_at IS NOT NULL").Where("posts.author_id = ? OR EXISTS (?)", userID, owns)
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, errors.Internal("unable to count posts", "Database error while counting trashed posts", err)
+	}
+
+	if err := query.Select(postSelect).Preload("Author").Preload("Collaborators", preloadCollaborators).Preload("Category").Preload("Tags", preloadTags).Preload("Media", preloadMedia).Preload("FeaturedMedia", preloadMedia).
+		Order("posts.deleted_at DESC, posts.id DESC").Limit(limit).Offset(offset).Find(&posts).Error; err != nil {
+		return nil, 0, errors.Internal("Unable to retrieve posts", "Database error while retrieving trashed posts", err)
+	}
+	return posts, count, nil
+}
+
+func (r *postRepository) FindTrashedByID(id uint) (*models.Post, error) {
+	var post models.Post
+	if err := r.db.Unscoped().Preload("Collaborators").Where("posts.deleted_at IS NOT NULL").First(&post, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NotFound("Post not found in trash",
+				fmt.Sprintf("Trashed post with id '%d' not found", id),
+			)
+		}
+		return nil, errors.Internal(
+			"Unable to find post",
+			"Database error while searching for trashed post by ID",
+			err)
+	}
+	return &post, nil
+}
+
+func (r *postRepository) Restore(id uint) error {
+	if err := r.db.Unscoped().Model(&models.Post{}).Where("id = ?", id).UpdateColumn("deleted_at", nil).Error; err != nil {
+		return errors.Internal("unable to restore post", "Database error while restoring post", err)
+	}
+	return r.markRelatedStale(id)
+}
+
+// Purge permanently deletes a post, live or trashed, together with everything attached to it
+func (r *postRepository) Purge(id uint) error {
+	// Lists that include the post are recomputed rather than left one short
+	if err := r.markRelatedStale(id); err != nil {
+		return err
+	}
+	result := r.db.Unscoped().Delete(&models.Post{}, id)
+	if result.Error != nil {
+		return errors.Internal("unable to purge post", "Database error while purging post", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.NotFound("Post not found", fmt.Sprintf("Post with id '%d' not found for purge", id))
+	}
+	return nil
+}
+
+// PurgeDeletedBefore permanently deletes posts that were trashed before the given time
+func (r *postRepository) PurgeDeletedBefore(before time.Time) (int64, error) {
+	result := r.db.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", before).Delete(&models.Post{})
+	if result.Error != nil {
+		return 0, errors.Internal("unable to purge posts", "Database error while purging trashed posts", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// AddTags attaches the named tags to the post, creating tags that don't exist yet, and bumps
+// the post version like any other edit. Tags the post already has are left as they are.
+func (r *postRepository) AddTags(post *models.Post, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	tags := make([]models.Tag, 0, len(names))
+	for _, name := range names {
+		tags = append(tags, models.Tag{Name: name})
+	}
+	if err := r.db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "name"}}, DoNothing: true}).Create(&tags).Error; err != nil {
+		return errors.Internal("unable to tag post", "Database error while creating tags", err)
+	}
+
+	tags = nil
+	if err := r.db.Where("name IN ?", names).Find(&tags).Error; err != nil {
+		return errors.Internal("unable to tag post", "Database error while loading tags", err)
+	}
+	if err := r.db.Model(post).Association("Tags").Append(tags); err != nil {
+		return errors.Internal("unable to tag post", "Database error while tagging post", err)
+	}
+	return r.Update(post)
+}
+
+func (r *postRepository) SetMedia(post *models.Post, media []models.Media) error {
+	association := r.db.Model(post).Association("Media")
+	var err error
+	if len(media) == 0 {
+		err = association.Clear()
+	} else {
+		err = association.Replace(media)
+	}
+	if err != nil {
+		return errors.Internal("unable to attach media", "Database error while replacing post media", err)
+	}
+	post.Media = media
+	return nil
+}
+
+func (r *postRepository) FindWithoutTextStats(afterID uint, limit int) ([]models.Post, error) {
+	var posts []models.Post
+	if err := r.db.Unscoped().Select("id", "description").Where("word_count = 0 AND id > ?", afterID).
+		Order("id").Limit(limit).Find(&posts).Error; err != nil {
+		return nil, errors.Internal("Unable to retrieve posts", "Database error while listing posts without text stats", err)
+	}
+	return posts, nil
+}
+
+func (r *postRepository) FindBySeries(seriesIDs []uint, publishedOnly bool) ([]models.Post, error) {
+	var posts []models.Post
+	if len(seriesIDs) == 0 {
+		return posts, nil
+	}
+
+	query := r.db.Select("id", "title", "excerpt", "auto_excerpt", "status", "author_id", "series_id", "series_position", "created_at").
+		Where("series_id IN ?", seriesIDs)
+	if publishedOnly {
+		query = query.Where("status = ?", models.PostStatusPublished)
+	}
+	if err := query.Order("series_id, series_position, id").Find(&posts).Error; err != nil {
+		return nil, errors.Internal("Unable to retrieve posts", "Database error while listing series posts", err)
+	}
+	return posts, nil
+}
+
+func (r *postRepository) SetSeriesPosts(seriesID uint, postIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// Trashed posts keep their place so they come back where they were when restored
+		removed := tx.Model(&models.Post{}).Where("series_id = ?", seriesID)
+		if len(postIDs) > 0 {
+			removed = removed.Where("id NOT IN ?", postIDs)
+		}
+		if err := removed.UpdateColumns(map[string]interface{}{"series_id": nil, "series_position": 0}).Error; err != nil {
+			return errors.Internal("unable to update series", "Database error while removing posts from series", err)
+		}
+
+		for i, id := range postIDs {
+			// A post may have joined another series since it was checked
+			result := tx.Model(&models.Post{}).Where("id = ? AND (series_id IS NULL OR series_id = ?)", id, seriesID).UpdateColumns(map[string]interface{}{
+				"series_id":       seriesID,
+				"series_position": i + 1,
+			})
+			if result.Error != nil {
+				return errors.Internal("unable to update series", "Database error while ordering series posts", result.Error)
+			}
+			if result.RowsAffected == 0 {
+				return errors.Conflict(
+					"The post is already part of another series",
+					fmt.Sprintf("Post '%d' can't join series '%d'", id, seriesID),
+				)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *postRepository) LockSeries(seriesID uint) error {
+	var series models.Series
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Take(&series, seriesID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.NotFound("Series not found", fmt.Sprintf("Series with id '%d' not found", seriesID))
+		}
+		return errors.Internal("unable to update series", "Database error while locking series", err)
+	}
+	return nil
+}
+
+func (r *postRepository) DeleteSeries(seriesID uint) error {
+	result := r.db.Delete(&models.Series{}, seriesID)
+	if result.Error != nil {
+		return errors.Internal("Unable to delete series", "Database error while deleting series", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.NotFound("Series not found", fmt.Sprintf("Series with id '%d' not found for delete", seriesID))
+	}
+	return nil
+}
+
+func (r *postRepository) FindTranslations(groupIDs []uint, publishedOnly bool) ([]models.Post, error) {
+	var posts []models.Post
+	if len(groupIDs) == 0 {
+		return posts, nil
+	}
+
+	query := r.db.Select("id", "title", "locale", "status", "author_id", "translation_group_id").Where("translation_group_id IN ?", groupIDs)
+	if publishedOnly {
+		query = query.Where("status = ?", models.PostStatusPublished)
+	}
+	if err := query.Order("id").Find(&posts).Error; err != nil {
+		return nil, errors.Internal("Unable to retrieve posts", "Database error while listing translations", err)
+	}
+	return posts, nil
+}
+
+func (r *postRepository) SetTranslationGroup(postIDs []uint, groupID *uint) error {
+	if len(postIDs) == 0 {
+		return nil
+	}
+	if err := r.db.Model(&models.Post{}).Where("id IN ?", postIDs).UpdateColumn("translation_group_id", groupID).Error; err != nil {
+		return errors.Internal("unable to link translations", "Database error while updating translation group", err)
+	}
+	return r.markRelatedStale(postIDs...)
+}
+
+func (r *postRepository) FindInvitations(userID uint) ([]models.PostCollaborator, error) {
+	var invitations []models.PostCollaborator
+	if err := r.db.Select("post_collaborators.*, posts.title AS post_title").
+		Joins("JOIN posts ON posts.id = post_collaborators.post_id AND posts.deleted_at IS NULL").
+		Where("post_collaborators.user_id = ? AND post_collaborators.status = ?", userID, models.CollaboratorStatusInvited).
+		Order("post_collaborators.created_at DESC, post_collaborators.id DESC").Find(&invitations).Error; err != nil {
+		return nil, errors.Internal("Unable to retrieve invitations", "Database error while listing post invitations", err)
+	}
+	return invitations, nil
+}
+
+func (r *postRepository) SaveCollaborator(collaborator *models.PostCollaborator) error {
+	if err := r.db.Omit(clause.Associations).Save(collaborator).Error; err != nil {
+		return errors.Internal("Unable to save collaborator", "Database error while saving post collaborator", err)
+	}
+	return nil
+}
+
+func (r *postRepository) RemoveCollaborator(collaborator *models.PostCollaborator) error {
+	if err := r.db.Delete(collaborator).Error; err != nil {
+		return errors.Internal("Unable to remove collaborator", "Database error while deleting post collaborator", err)
+	}
+	return nil
+}
+
+func (r *postRepository) UpdateTextStats(posts []models.Post) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, post := range posts {
+			if err := tx.Unscoped().Model(&models.Post{}).Where("id = ?", post.ID).UpdateColumns(map[string]interface{}{
+				"auto_excerpt": post.AutoExcerpt,
+				"word_count":   post.WordCount,
+				"reading_time": post.ReadingTime,
+			}).Error; err != nil {
+				return errors.Internal("unable to update post", "Database error while updating post text stats", err)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *postRepository) Transaction(fn func(tx PostRepository) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&postRepository{db: tx, searchLanguage: r.searchLanguage})
+	})
+}
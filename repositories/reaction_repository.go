@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"crud_api/errors"
+	"crud_api/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReactionRepository interface {
+	Add(reaction *models.Reaction) error
+	Remove(postID, userID uint, reactionType string) error
+	CountsByPosts(postIDs []uint) (map[uint]map[string]int64, error)
+	TypesByUser(postIDs []uint, userID uint) (map[uint][]string, error)
+}
+
+type reactionRepository struct {
+	db *gorm.DB
+}
+
+func NewReactionRepository(db *gorm.DB) ReactionRepository {
+	return &reactionRepository{db}
+}
+
+func (r *reactionRepository) Add(reaction *models.Reaction) error {
+	// Reacting twice with the same type is a no-op thanks to the unique index
+	if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(reaction).Error; err != nil {
+		return errors.Internal(
+			"Unable to add reaction",
+			"Database error while creating reaction",
+			err,
+		)
+	}
+	return nil
+}
+
+func (r *reactionRepository) Remove(postID, userID uint, reactionType string) error {
+	if err := r.db.Where("post_id = ? AND user_id = ? AND type = ?", postID, userID, reactionType).
+		Delete(&models.Reaction{}).Error; err != nil {
+		return errors.Internal(
+			"Unable to remove reaction",
+			"Database error while deleting reaction",
+			err,
+		)
+	}
+	return nil
+}
+
+func (r *reactionRepository) CountsByPosts(postIDs []uint) (map[uint]map[string]int64, error) {
+	counts := make(map[uint]map[string]int64)
+	if len(postIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		PostID uint
+		Type   string
+		Count  int64
+	}
+	if err := r.db.Model(&models.Reaction{}).
+		Select("post_id, type, COUNT(*) AS count").
+		Where("post_id IN ?", postIDs).
+		Group("post_id, type").
+		Scan(&rows).Error; err != nil {
+		return nil, errors.Internal("Unable to retrieve reactions", "Database error while counting reactions", err)
+	}
+
+	for _, row := range rows {
+		if counts[row.PostID] == nil {
+			counts[row.PostID] = make(map[string]int64)
+		}
+		counts[row.PostID][row.Type] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *reactionRepository) TypesByUser(postIDs []uint, userID uint) (map[uint][]string, error) {
+	types := make(map[uint][]string)
+	if len(postIDs) == 0 {
+		return types, nil
+	}
+
+	var reactions []models.Reaction
+	if err := r.db.Where("post_id IN ? AND user_id = ?", postIDs, userID).
+		Order("created_at ASC").
+		Find(&reactions).Error; err != nil {
+		return nil, errors.Internal("Unable to retrieve reactions", "Database error while listing user reactions", err)
+	}
+
+	for _, reaction := range reactions {
+		types[reaction.PostID] = append(types[reaction.PostID], reaction.Type)
+	}
+	return types, nil
+}
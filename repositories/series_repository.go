@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"crud_api/errors"
+	"crud_api/models"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// SeriesRepository stores the series themselves; which posts belong to a series is kept on
+// the posts and changed through PostRepository.SetSeriesPosts
+type SeriesRepository interface {
+	Create(series *models.Series) error
+	FindByID(id uint) (*models.Series, error)
+	FindByIDs(ids []uint) ([]models.Series, error)
+	ListByOwner(ownerID uint, limit, offset int) ([]models.Series, int64, error)
+	Update(series *models.Series) error
+}
+
+type seriesRepository struct {
+	db *gorm.DB
+}
+
+func NewSeriesRepository(db *gorm.DB) SeriesRepository {
+	return &seriesRepository{db: db}
+}
+
+func (r *seriesRepository) Create(series *models.Series) error {
+	if err := r.db.Create(series).Error; err != nil {
+		return errors.Internal(
+			"Unable to create series",
+			"Database error while creating series",
+			err,
+		)
+	}
+	return nil
+}
+
+func (r *seriesRepository) FindByID(id uint) (*models.Series, error) {
+	var series models.Series
+	if err := r.db.First(&series, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NotFound("Series not found",
+				fmt.Sprintf("Series with id '%d' not found", id),
+			)
+		}
+		return nil, errors.Internal(
+			"Unable to find series",
+			"Database error while searching for series by ID",
+			err)
+	}
+	return &series, nil
+}
+
+// FindByIDs returns the series that exist among ids, in ID order
+func (r *seriesRepository) FindByIDs(ids []uint) ([]models.Series, error) {
+	var series []models.Series
+	if len(ids) == 0 {
+		return series, nil
+	}
+	if err := r.db.Where("id IN ?", ids).Order("id").Find(&series).Error; err != nil {
+		return nil, errors.Internal("Unable to find series", "Database error while loading series by IDs", err)
+	}
+	return series, nil
+}
+
+// ListByOwner lists the user's series, most recently created first
+func (r *seriesRepository) ListByOwner(ownerID uint, limit, offset int) ([]models.Series, int64, error) {
+	var series []models.Series
+	var total int64
+
+	query := r.db.Model(&models.Series{}).Where("owner_id = ?", ownerID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, errors.Internal("Unable to retrieve series", "Database error while counting series", err)
+	}
+	if err := query.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&series).Error; err != nil {
+		return nil, 0, errors.Internal("Unable to retrieve series", "Database error while listing series", err)
+	}
+	return series, total, nil
+}
+
+func (r *seriesRepository) Update(series *models.Series) error {
+	if err := r.db.Model(series).Select("title", "description").Updates(series).Error; err != nil {
+		return errors.Internal("Unable to update series", "Database error while updating series", err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"crud_api/errors"
+	"crud_api/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PageLastMod is a public page and the last time its content changed
+type PageLastMod struct {
+	ID      uint
+	LastMod time.Time
+}
+
+// SitemapRepository lists the public pages of the blog, ordered by ID
+type SitemapRepository interface {
+	Posts() ([]PageLastMod, error)
+	Categories() ([]PageLastMod, error)
+	Authors() ([]PageLastMod, error)
+}
+
+type sitemapRepository struct {
+	db *gorm.DB
+}
+
+func NewSitemapRepository(db *gorm.DB) SitemapRepository {
+	return &sitemapRepository{db: db}
+}
+
+func (r *sitemapRepository) publishedPosts() *gorm.DB {
+	return r.db.Model(&models.Post{}).Where("posts.status = ?", models.PostStatusPublished)
+}
+
+func (r *sitemapRepository) Posts() ([]PageLastMod, error) {
+	var pages []PageLastMod
+	if err := r.publishedPosts().Select("posts.id AS id, posts.updated_at AS last_mod").Order("posts.id").Scan(&pages).Error; err != nil {
+		return nil, errors.Internal("Unable to build sitemap", "Database error while listing posts for the sitemap", err)
+	}
+	return pages, nil
+}
+
+// Categories lists every category; one changes whenever one of its published posts does
+func (r *sitemapRepository) Categories() ([]PageLastMod, error) {
+	var pages []PageLastMod
+	err := r.db.Model(&models.Category{}).
+		Select("categories.id AS id, COALESCE(MAX(posts.updated_at), categories.updated_at) AS last_mod").
+		Joins("LEFT JOIN posts ON posts.category_id = categories.id AND posts.deleted_at IS NULL AND posts.status = ?", models.PostStatusPublished).
+		Group("categories.id").Order("categories.id").Scan(&pages).Error
+	if err != nil {
+		return nil, errors.Internal("Unable to build sitemap", "Database error while listing categories for the sitemap", err)
+	}
+	return pages, nil
+}
+
+// Authors lists active users with at least one published post
+func (r *sitemapRepository) Authors() ([]PageLastMod, error) {
+	var pages []PageLastMod
+	err := r.publishedPosts().Select("posts.author_id AS id, MAX(posts.updated_at) AS last_mod").
+		Joins("JOIN users ON users.id = posts.author_id AND users.deleted_at IS NULL AND NOT users.disabled").
+		Group("posts.author_id").Order("posts.author_id").Scan(&pages).Error
+	if err != nil {
+		return nil, errors.Internal("Unable to build sitemap", "Database error while listing authors for the sitemap", err)
+	}
+	return pages, nil
+}
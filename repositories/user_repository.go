@@ -13,6 +13,9 @@ type UserRepository interface {
 	FindByEmail(email string) (*models.User, error)
 	FindAll() ([]models.User, error)
 	FindByID(id uint) (*models.User, error)
+	UpdateRole(id uint, role string) error
+	SetDisabled(id uint, disabled bool) error
+	Delete(id uint) error
 }
 
 type userRepository struct {
@@ -34,7 +37,8 @@ func (r *userRepository) Create(user *models.User) error {
 
 func (r *userRepository) FindByEmail(email string) (*models.User, error) {
 	var user models.User
-	if err := r.db.Where("email = ?", email).First(&user).Error; err != nil {
+	// Email addresses are matched regardless of case, as mail servers treat them
+	if err := r.db.Where("LOWER(email) = LOWER(?)", email).First(&user).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, errors.NotFound("User not found",
 				fmt.Sprintf("user with email '%s' not found", email))
@@ -65,6 +69,37 @@ func (r *userRepository) FindByID(id uint) (*models.User, error) {
 	return &user, nil
 }
 
+func (r *userRepository) UpdateRole(id uint, role string) error {
+	if err := r.db.Model(&models.User{}).Where("id = ?", id).Update("role", role).Error; err != nil {
+		return errors.Internal("Unable to update user",
+			"Database error while updating user role",
+			err)
+	}
+	return nil
+}
+
+func (r *userRepository) SetDisabled(id uint, disabled bool) error {
+	if err := r.db.Model(&models.User{}).Where("id = ?", id).Update("disabled", disabled).Error; err != nil {
+		return errors.Internal("Unable to update user",
+			"Database error while updating user status",
+			err)
+	}
+	return nil
+}
+
+func (r *userRepository) Delete(id uint) error {
+	result := r.db.Delete(&models.User{}, id)
+	if result.Error != nil {
+		return errors.Internal("Unable to delete user",
+			"Database error while deleting user",
+			result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.NotFound("User not found", fmt.Sprintf("user with id '%d' not found", id))
+	}
+	return nil
+}
+
 func (r *userRepository) test() {
 	//aaaaaaaaaaaaaaaaaaa
 	//aaaaaaaaaaaaaaaaaaa
@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"crud_api/errors"
+	"crud_api/models"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ViewRepository interface {
+	RecordVisits(visits []models.PostViewVisitor) error
+	DailyViews(postID uint, from, to time.Time) ([]models.PostDailyViews, error)
+	TotalViews(postID uint) (int64, error)
+	PruneVisitors(before time.Time) error
+}
+
+type viewRepository struct {
+	db *gorm.DB
+}
+
+func NewViewRepository(db *gorm.DB) ViewRepository {
+	return &viewRepository{db}
+}
+
+// RecordVisits stores the visitors and bumps the daily counters by the number of visitors
+// that were not seen before, in a single statement. Views of posts purged in the meantime are
+// dropped rather than failing the whole batch.
+func (r *viewRepository) RecordVisits(visits []models.PostViewVisitor) error {
+	if len(visits) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, 0, len(visits))
+	args := make([]interface{}, 0, len(visits)*3)
+	for _, visit := range visits {
+		placeholders = append(placeholders, "(?::bigint, ?::date, ?::text)")
+		args = append(args, visit.PostID, visit.Day.Format("2006-01-02"), visit.VisitorHash)
+	}
+
+	sql := "WITH inserted AS (" +
+		"INSERT INTO post_view_visitors (post_id, day, visitor_hash) " +
+		"SELECT visits.post_id, visits.day, visits.visitor_hash FROM (VALUES " + strings.Join(placeholders, ", ") + ") AS visits (post_id, day, visitor_hash) " +
+		"WHERE EXISTS (SELECT 1 FROM posts WHERE posts.id = visits.post_id) " +
+		"ON CONFLICT DO NOTHING RETURNING post_id, day" +
+		") " +
+		"INSERT INTO post_daily_views (post_id, day, views) " +
+		"SELECT post_id, day, COUNT(*) FROM inserted GROUP BY post_id, day " +
+		"ON CONFLICT (post_id, day) DO UPDATE SET views = post_daily_views.views + EXCLUDED.views"
+
+	if err := r.db.Exec(sql, args...).Error; err != nil {
+		return errors.Internal("Unable to record post views", "Database error while recording post views", err)
+	}
+	return nil
+}
+
+func (r *viewRepository) DailyViews(postID uint, from, to time.Time) ([]models.PostDailyViews, error) {
+	var days []models.PostDailyViews
+	if err := r.db.
+		Where("post_id = ? AND day BETWEEN ?::date AND ?::date", postID, from.Format("2006-01-02"), to.Format("2006-01-02")).
+		Order("day ASC").
+		Find(&days).Error; err != nil {
+		return nil, errors.Internal("Unable to retrieve post statistics", "Database error while listing daily views", err)
+	}
+	return days, nil
+}
+
+func (r *viewRepository) TotalViews(postID uint) (int64, error) {
+	var total int64
+	if err := r.db.Model(&models.PostDailyViews{}).
+		Select("COALESCE(SUM(views), 0)").
+		Where("post_id = ?", postID).
+		Scan(&total).Error; err != nil {
+		return 0, errors.Internal("Unable to retrieve post statistics", "Database error while summing post views", err)
+	}
+	return total, nil
+}
+
+func (r *viewRepository) PruneVisitors(before time.Time) error {
+	if err := r.db.Where("day < ?::date", before.Format("2006-01-02")).
+		Delete(&models.PostViewVisitor{}).Error; err != nil {
+		return errors.Internal("Unable to prune view visitors", "Database error while pruning view visitors", err)
+	}
+	return nil
+}
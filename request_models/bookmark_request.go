@@ -0,0 +1,25 @@
+package requestmodels
+
+import (
+	"crud_api/models"
+	"strings"
+)
+
+type BookmarkRequest struct {
+	Folder string `json:"folder,omitempty" validate:"max=100"` // optional, empty means unfiled
+	Note   string `json:"note,omitempty" validate:"max=1000"`  // optional
+}
+
+func FromBookmarkRequest(req BookmarkRequest, userID, postID uint) models.Bookmark {
+	return models.Bookmark{
+		UserID: userID,
+		PostID: postID,
+		Folder: req.Folder,
+		Note:   req.Note,
+	}
+}
+
+func (r *BookmarkRequest) Sanitize() {
+	r.Folder = strings.TrimSpace(r.Folder)
+	r.Note = strings.TrimSpace(r.Note)
+}
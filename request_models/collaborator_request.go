@@ -0,0 +1,11 @@
+package requestmodels
+
+type InviteCollaboratorRequest struct {
+	UserID uint   `json:"user_id" validate:"required"`
+	Role   string `json:"role" validate:"required,oneof=owner co-author reviewer"`
+}
+
+// CollaboratorRoleRequest is the document PATCH requests on a collaborator apply to
+type CollaboratorRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=owner co-author reviewer"`
+}
@@ -0,0 +1,57 @@
+package requestmodels
+
+import (
+	"crud_api/models"
+	"strings"
+)
+
+type CreateCommentRequest struct {
+	Body     string `json:"body" validate:"required"`
+	ParentID *uint  `json:"parent_id,omitempty"` // optional, set when replying to another comment
+}
+
+type UpdateCommentRequest struct {
+	Body string `json:"body" validate:"required"`
+}
+
+type BulkModerateCommentsRequest struct {
+	IDs    []uint `json:"ids" validate:"required"`
+	Action string `json:"action" validate:"required,oneof=approve reject spam"`
+}
+
+type CommentLockRequest struct {
+	Locked bool `json:"locked"`
+}
+
+// moderationActions maps the bulk moderation actions to the resulting comment status
+var moderationActions = map[string]string{
+	"approve": models.CommentStatusApproved,
+	"reject":  models.CommentStatusRejected,
+	"spam":    models.CommentStatusSpam,
+}
+
+// Status returns the comment status for the requested action, or "" if the action is unknown
+func (r BulkModerateCommentsRequest) Status() string {
+	return moderationActions[r.Action]
+}
+
+func FromCreateCommentRequest(req CreateCommentRequest, postID, authorID uint) models.Comment {
+	return models.Comment{
+		PostID:   postID,
+		AuthorID: authorID,
+		ParentID: req.ParentID,
+		Body:     req.Body,
+	}
+}
+
+func FromUpdateCommentRequest(comment *models.Comment, req UpdateCommentRequest) {
+	comment.Body = req.Body
+}
+
+func (r *CreateCommentRequest) Sanitize() {
+	r.Body = strings.TrimSpace(r.Body)
+}
+
+func (r *UpdateCommentRequest) Sanitize() {
+	r.Body = strings.TrimSpace(r.Body)
+}
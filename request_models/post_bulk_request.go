@@ -0,0 +1,35 @@
+package requestmodels
+
+import (
+	"strings"
+	"time"
+)
+
+// BulkPostRequest applies one action to the posts selected by ids or, alternatively, by filter
+type BulkPostRequest struct {
+	Action string          `json:"action" validate:"required,oneof=delete move_category set_status add_tags"`
+	IDs    []uint          `json:"ids,omitempty"`
+	Filter *BulkPostFilter `json:"filter,omitempty"`
+
+	CategoryID uint     `json:"category_id,omitempty"` // move_category
+	Status     string   `json:"status,omitempty"`      // set_status
+	Tags       []string `json:"tags,omitempty"`        // add_tags
+}
+
+// BulkPostFilter selects posts like the listing filters do; at least one condition is required
+type BulkPostFilter struct {
+	Search        string     `json:"search,omitempty"`
+	CategoryIDs   []int      `json:"category_ids,omitempty"`
+	AuthorIDs     []int      `json:"author_ids,omitempty"`
+	Statuses      []string   `json:"statuses,omitempty"`
+	CreatedAfter  *time.Time `json:"created_after,omitempty"`
+	CreatedBefore *time.Time `json:"created_before,omitempty"`
+}
+
+func (r *BulkPostRequest) Sanitize() {
+	r.Action = strings.TrimSpace(r.Action)
+	r.Status = strings.TrimSpace(r.Status)
+	if r.Filter != nil {
+		r.Filter.Search = strings.TrimSpace(r.Filter.Search)
+	}
+}
@@ -10,35 +10,143 @@ type CreatePostRequest struct {
 	Title       string `json:"title" validate:"required"`
 	Description string `json:"description" validate:"required"`
 	CategoryID  uint   `json:"category_id,omitempty"` // optional
+	Status      string `json:"status,omitempty"`      // draft, published (default) or archived
+	MediaIDs    []uint `json:"media_ids,omitempty"`   // own uploads to attach
+	Locale      string `json:"locale,omitempty"`      // language of the post, the default language when empty
+	PostSEORequest
 }
 
+// UpdatePostRequest holds the editable fields of a post. Edits are sent as patches against
+// it, so the whole struct is validated after the patch has been applied.
 type UpdatePostRequest struct {
 	Title       string `json:"title" validate:"required"`
 	Description string `json:"description" validate:"required"`
-	CategoryID  uint   `json:"category_id,omitempty"` // optional
+	CategoryID  uint   `json:"category_id" validate:"required"`
+	Status      string `json:"status" validate:"required,oneof=draft published archived"`
+	MediaIDs    []uint `json:"media_ids"`
+	Locale      string `json:"locale" validate:"required"`
+	PostSEORequest
+}
+
+// PostSEORequest holds the featured image and the search engine and social preview
+// metadata of a post. Empty fields fall back to the post content.
+type PostSEORequest struct {
+	FeaturedMediaID *uint  `json:"featured_media_id"` // own image upload
+	Excerpt         string `json:"excerpt"`           // generated from the description when empty
+	MetaTitle       string `json:"meta_title"`
+	MetaDescription string `json:"meta_description"`
+	CanonicalURL    string `json:"canonical_url"`
+	OGTitle         string `json:"og_title"`
+	OGDescription   string `json:"og_description"`
+	TwitterCard     string `json:"twitter_card"` // summary or summary_large_image
 }
 
-func (u UpdatePostRequest) Sanitize() {
-	panic("unimplemented")
+func (r *PostSEORequest) Sanitize() {
+	r.Excerpt = strings.TrimSpace(r.Excerpt)
+	r.MetaTitle = strings.TrimSpace(r.MetaTitle)
+	r.MetaDescription = strings.TrimSpace(r.MetaDescription)
+	r.CanonicalURL = strings.TrimSpace(r.CanonicalURL)
+	r.OGTitle = strings.TrimSpace(r.OGTitle)
+	r.OGDescription = strings.TrimSpace(r.OGDescription)
+	r.TwitterCard = strings.TrimSpace(r.TwitterCard)
+}
+
+func (r PostSEORequest) apply(post *models.Post) {
+	post.FeaturedMediaID = r.FeaturedMediaID
+	post.Excerpt = r.Excerpt
+	post.MetaTitle = r.MetaTitle
+	post.MetaDescription = r.MetaDescription
+	post.CanonicalURL = r.CanonicalURL
+	post.OGTitle = r.OGTitle
+	post.OGDescription = r.OGDescription
+	post.TwitterCard = r.TwitterCard
+}
+
+func toPostSEORequest(post models.Post) PostSEORequest {
+	return PostSEORequest{
+		FeaturedMediaID: post.FeaturedMediaID,
+		Excerpt:         post.Excerpt,
+		MetaTitle:       post.MetaTitle,
+		MetaDescription: post.MetaDescription,
+		CanonicalURL:    post.CanonicalURL,
+		OGTitle:         post.OGTitle,
+		OGDescription:   post.OGDescription,
+		TwitterCard:     post.TwitterCard,
+	}
+}
+
+func (r *UpdatePostRequest) Sanitize() {
+	r.Title = strings.TrimSpace(r.Title)
+	r.Description = strings.TrimSpace(r.Description)
+	r.Locale = strings.TrimSpace(r.Locale)
+	r.PostSEORequest.Sanitize()
+}
+
+// ToUpdatePostRequest returns the editable fields of a post, the document patches apply to
+func ToUpdatePostRequest(post models.Post) UpdatePostRequest {
+	return UpdatePostRequest{
+		Title:       post.Title,
+		Description: post.Description,
+		CategoryID:  post.CategoryID,
+		Status:      post.Status,
+		MediaIDs:    mediaIDs(post.Media),
+		Locale:      post.Locale,
+
+		PostSEORequest: toPostSEORequest(post),
+	}
 }
 
 func FromCreatePostRequest(req CreatePostRequest, authorID uint) models.Post {
-	return models.Post{
+	post := models.Post{
 		Title:       req.Title,
 		Description: req.Description,
 		CategoryID:  req.CategoryID,
+		Status:      req.Status,
 		AuthorID:    authorID,
+		Media:       mediaRefs(req.MediaIDs),
+		Locale:      req.Locale,
 	}
+	req.PostSEORequest.apply(&post)
+	return post
 }
 
 func FromUpdatePostRequest(post *models.Post, req UpdatePostRequest) {
 	post.Title = req.Title
 	post.Description = req.Description
-	post.CategoryID = req.CategoryID
-	post.Category = models.Category{}
+	post.Status = req.Status
+	post.Media = mediaRefs(req.MediaIDs)
+	post.Locale = req.Locale
+	req.PostSEORequest.apply(post)
+	if post.CategoryID != req.CategoryID {
+		post.CategoryID = req.CategoryID
+		post.Category = models.Category{}
+	}
 }
 
 func (r *CreatePostRequest) Sanitize() {
 	r.Title = strings.TrimSpace(r.Title)
 	r.Description = strings.TrimSpace(r.Description)
+	r.Locale = strings.TrimSpace(r.Locale)
+	r.PostSEORequest.Sanitize()
+}
+
+// mediaRefs references media by ID; the post service loads and checks them
+func mediaRefs(ids []uint) []models.Media {
+	media := make([]models.Media, 0, len(ids))
+	for _, id := range ids {
+		media = append(media, models.Media{ID: id})
+	}
+	return media
+}
+
+func mediaIDs(media []models.Media) []uint {
+	ids := make([]uint, 0, len(media))
+	for _, m := range media {
+		ids = append(ids, m.ID)
+	}
+	return ids
+}
+
+type PostTranslationRequest struct {
+	PostID uint `json:"post_id" validate:"required"` // post to link as a translation, in another language
 }
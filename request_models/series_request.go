@@ -0,0 +1,46 @@
+package requestmodels
+
+import (
+	"crud_api/models"
+	"strings"
+)
+
+// SeriesRequest holds the editable fields of a series; it is also the document PATCH
+// requests apply to
+type SeriesRequest struct {
+	Title       string `json:"title" validate:"required,max=200"`
+	Description string `json:"description" validate:"max=5000"` // optional
+}
+
+type SeriesPostRequest struct {
+	PostID uint `json:"post_id" validate:"required"` // own post, added as the last part
+}
+
+type SeriesOrderRequest struct {
+	PostIDs []uint `json:"post_ids" validate:"required"` // every part of the series, in reading order
+}
+
+func ToSeriesRequest(series models.Series) SeriesRequest {
+	return SeriesRequest{
+		Title:       series.Title,
+		Description: series.Description,
+	}
+}
+
+func FromSeriesRequest(req SeriesRequest, ownerID uint) models.Series {
+	return models.Series{
+		Title:       req.Title,
+		Description: req.Description,
+		OwnerID:     ownerID,
+	}
+}
+
+func ApplySeriesRequest(series *models.Series, req SeriesRequest) {
+	series.Title = req.Title
+	series.Description = req.Description
+}
+
+func (r *SeriesRequest) Sanitize() {
+	r.Title = strings.TrimSpace(r.Title)
+	r.Description = strings.TrimSpace(r.Description)
+}
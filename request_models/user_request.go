@@ -16,6 +16,14 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
+type UpdateRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=user moderator admin"`
+}
+
+type UpdateUserStatusRequest struct {
+	Disabled bool `json:"disabled"`
+}
+
 func FromUserCreateRequest(u CreateUserRequest) models.User {
 	return models.User{
 		Name:     u.Name,
@@ -0,0 +1,38 @@
+package responsemodels
+
+import (
+	"crud_api/models"
+	"time"
+)
+
+type BookmarkResponse struct {
+	ID        uint          `json:"id"`
+	PostID    uint          `json:"post_id"`
+	Folder    string        `json:"folder"`
+	Note      string        `json:"note"`
+	Created   string        `json:"created_at"`
+	Available bool          `json:"available"` // false once the post has been deleted
+	Post      *PostResponse `json:"post"`
+}
+
+func ToBookmarkResponse(b models.Bookmark) BookmarkResponse {
+	resp := BookmarkResponse{
+		ID:        b.ID,
+		PostID:    b.PostID,
+		Folder:    b.Folder,
+		Note:      b.Note,
+		Created:   b.CreatedAt.Format(time.RFC3339),
+		Available: b.Post.ID != 0 && !b.Post.DeletedAt.Valid,
+	}
+
+	if resp.Available {
+		post := ToPostResponse(b.Post)
+		resp.Post = &post
+	}
+	return resp
+}
+
+type BookmarkFolderResponse struct {
+	Folder string `json:"folder"`
+	Count  int64  `json:"count"`
+}
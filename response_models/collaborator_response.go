@@ -0,0 +1,52 @@
+package responsemodels
+
+import (
+	"crud_api/models"
+	"time"
+)
+
+type CollaboratorResponse struct {
+	User        AuthorInfo `json:"user"`
+	Role        string     `json:"role"`   // owner, co-author or reviewer
+	Status      string     `json:"status"` // invited or accepted
+	InvitedByID uint       `json:"invited_by_id"`
+	Created     string     `json:"created_at"`
+	Accepted    *string    `json:"accepted_at"`
+}
+
+type InvitationResponse struct {
+	PostID      uint   `json:"post_id"`
+	PostTitle   string `json:"post_title"`
+	Role        string `json:"role"`
+	InvitedByID uint   `json:"invited_by_id"`
+	Created     string `json:"created_at"`
+}
+
+func ToCollaboratorResponse(c models.PostCollaborator) CollaboratorResponse {
+	response := CollaboratorResponse{
+		User: AuthorInfo{
+			ID:    c.User.ID,
+			Name:  c.User.Name,
+			Email: c.User.Email,
+		},
+		Role:        c.Role,
+		Status:      c.Status,
+		InvitedByID: c.InvitedByID,
+		Created:     c.CreatedAt.Format(time.RFC3339),
+	}
+	if c.AcceptedAt != nil {
+		accepted := c.AcceptedAt.Format(time.RFC3339)
+		response.Accepted = &accepted
+	}
+	return response
+}
+
+func ToInvitationResponse(c models.PostCollaborator) InvitationResponse {
+	return InvitationResponse{
+		PostID:      c.PostID,
+		PostTitle:   c.PostTitle,
+		Role:        c.Role,
+		InvitedByID: c.InvitedByID,
+		Created:     c.CreatedAt.Format(time.RFC3339),
+	}
+}
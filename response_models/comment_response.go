@@ -0,0 +1,50 @@
+package responsemodels
+
+import (
+	"crud_api/models"
+	"time"
+)
+
+type CommentResponse struct {
+	ID       uint              `json:"id"`
+	PostID   uint              `json:"post_id"`
+	ParentID *uint             `json:"parent_id"`
+	Body     string            `json:"body"`
+	Status   string            `json:"status"`
+	Deleted  bool              `json:"deleted"`
+	Author   *AuthorInfo       `json:"author"`
+	Created  string            `json:"created_at"`
+	Updated  string            `json:"updated_at"`
+	Replies  []CommentResponse `json:"replies,omitempty"`
+}
+
+func ToCommentResponse(c models.Comment) CommentResponse {
+	resp := CommentResponse{
+		ID:       c.ID,
+		PostID:   c.PostID,
+		ParentID: c.ParentID,
+		Body:     c.Body,
+		Status:   c.Status,
+		Deleted:  c.Status == models.CommentStatusDeleted,
+		Created:  c.CreatedAt.Format(time.RFC3339),
+		Updated:  c.UpdatedAt.Format(time.RFC3339),
+	}
+
+	// Deleted placeholders don't reveal who wrote them
+	if !resp.Deleted {
+		resp.Author = &AuthorInfo{
+			ID:    c.Author.ID,
+			Name:  c.Author.Name,
+			Email: c.Author.Email,
+		}
+	}
+
+	for _, reply := range c.Replies {
+		resp.Replies = append(resp.Replies, ToCommentResponse(reply))
+	}
+	return resp
+}
+
+type ModerationResultResponse struct {
+	Updated int64 `json:"updated"`
+}
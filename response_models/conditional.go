@@ -0,0 +1,67 @@
+package responsemodels
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ConditionalJSONResponse sends a 200 JSONResponse carrying an ETag (a hash of the body, after
+// etagPrefix when one is given) and Last-Modified (unless zero), or 304 Not Modified when the
+// client's If-None-Match / If-Modified-Since show its copy is still current
+func ConditionalJSONResponse(c echo.Context, message string, data interface{}, etagPrefix string, lastModified time.Time) error {
+	body, err := json.Marshal(JSONResponseStruct{
+		Message: message,
+		Data:    data,
+	})
+	if err != nil {
+		return err
+	}
+	return ConditionalBlobResponse(c, echo.MIMEApplicationJSON, body, etagPrefix, lastModified)
+}
+
+// ConditionalBlobResponse is ConditionalJSONResponse for an already rendered body of any content type
+func ConditionalBlobResponse(c echo.Context, contentType string, body []byte, etagPrefix string, lastModified time.Time) error {
+	sum := sha256.Sum256(body)
+	tag := hex.EncodeToString(sum[:8])
+	if etagPrefix != "" {
+		tag = etagPrefix + "-" + tag
+	}
+	etag := `"` + tag + `"`
+
+	header := c.Response().Header()
+	header.Set("ETag", etag)
+	if !lastModified.IsZero() {
+		header.Set(echo.HeaderLastModified, lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(c.Request(), etag, lastModified) {
+		return c.NoContent(http.StatusNotModified)
+	}
+	return c.Blob(http.StatusOK, contentType, body)
+}
+
+// notModified evaluates the GET preconditions; If-None-Match takes precedence over If-Modified-Since
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if header := r.Header.Get("If-None-Match"); header != "" {
+		for _, tag := range strings.Split(header, ",") {
+			// If-None-Match uses weak comparison
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if header := r.Header.Get(echo.HeaderIfModifiedSince); header != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(header)
+		return err == nil && !lastModified.Truncate(time.Second).After(since)
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package responsemodels
+
+import (
+	"crud_api/models"
+	"time"
+)
+
+type MediaResponse struct {
+	ID          uint                   `json:"id"`
+	OwnerID     uint                   `json:"owner_id"`
+	URL         string                 `json:"url"`
+	Filename    string                 `json:"filename"`
+	ContentType string                 `json:"content_type"`
+	Size        int64                  `json:"size"`
+	Width       int                    `json:"width"`
+	Height      int                    `json:"height"`
+	Status      string                 `json:"status"` // processing, ready or failed
+	Variants    []MediaVariantResponse `json:"variants"`
+	Created     string                 `json:"created_at"`
+}
+
+type MediaVariantResponse struct {
+	Name        string `json:"name"`
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	Size        int64  `json:"size"`
+}
+
+func ToMediaResponse(m models.Media) MediaResponse {
+	response := MediaResponse{
+		ID:          m.ID,
+		OwnerID:     m.OwnerID,
+		URL:         m.URL,
+		Filename:    m.Filename,
+		ContentType: m.ContentType,
+		Size:        m.Size,
+		Width:       m.Width,
+		Height:      m.Height,
+		Status:      m.Status,
+		Variants:    []MediaVariantResponse{},
+		Created:     m.CreatedAt.Format(time.RFC3339),
+	}
+	for _, variant := range m.Variants {
+		response.Variants = append(response.Variants, MediaVariantResponse{
+			Name:        variant.Name,
+			URL:         variant.URL,
+			ContentType: variant.ContentType,
+			Width:       variant.Width,
+			Height:      variant.Height,
+			Size:        variant.Size,
+		})
+	}
+	return response
+}
@@ -60,3 +60,43 @@ func GetPagination(c echo.Context) Pagination {
 		Offset: offset,
 	}
 }
+
+type CursorPaginatedResponse struct {
+	Data       interface{} `json:"data"`
+	Limit      int         `json:"limit"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+	PrevCursor string      `json:"prev_cursor,omitempty"`
+	Total      *int64      `json:"total,omitempty"`
+}
+
+// NewCursorPaginatedResponse constructs a CursorPaginatedResponse; nextCursor is empty on the last page
+func NewCursorPaginatedResponse(data interface{}, limit int, nextCursor string) CursorPaginatedResponse {
+	return CursorPaginatedResponse{
+		Data:       data,
+		Limit:      limit,
+		NextCursor: nextCursor,
+	}
+}
+
+type CursorPagination struct {
+	Limit  int
+	After  string
+	Before string
+}
+
+// GetCursorPagination extracts the opaque cursor and page size from query params
+func GetCursorPagination(c echo.Context) CursorPagination {
+	limit, err := strconv.Atoi(c.QueryParam("limit"))
+	if err != nil || limit <= 0 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	return CursorPagination{
+		Limit:  limit,
+		After:  c.QueryParam("after"),
+		Before: c.QueryParam("before"),
+	}
+}
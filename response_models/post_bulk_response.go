@@ -0,0 +1,31 @@
+package responsemodels
+
+import "crud_api/errors"
+
+// BulkPostResponse reports the outcome of a bulk operation post by post
+type BulkPostResponse struct {
+	Action    string               `json:"action"`
+	Succeeded int                  `json:"succeeded"`
+	Failed    int                  `json:"failed"`
+	Results   []BulkPostItemResult `json:"results"`
+}
+
+// BulkPostItemResult is the outcome for one post; failed items carry the HTTP status and
+// message the same change would have produced on its own
+type BulkPostItemResult struct {
+	ID     uint   `json:"id"`
+	Status string `json:"status" example:"ok"` // ok or failed
+	Code   int    `json:"code,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Add records the outcome for one post
+func (r *BulkPostResponse) Add(id uint, err *errors.AppErrors) {
+	if err == nil {
+		r.Succeeded++
+		r.Results = append(r.Results, BulkPostItemResult{ID: id, Status: "ok"})
+		return
+	}
+	r.Failed++
+	r.Results = append(r.Results, BulkPostItemResult{ID: id, Status: "failed", Code: err.Code, Error: err.Message})
+}
@@ -2,16 +2,167 @@ package responsemodels
 
 import (
 	"crud_api/models"
+	"fmt"
 	"time"
 )
 
+// PostURL returns the absolute address of a post on the public website, used for og:url and the
+// series and translation links; routes points it at the configured SITE_URL
+var PostURL = func(id uint) string {
+	return fmt.Sprintf("http://localhost:8000/posts/%d", id)
+}
+
 type PostResponse struct {
-	ID          uint         `json:"id"`
-	Title       string       `json:"title"`
-	Description string       `json:"description"`
-	Author      AuthorInfo   `json:"author"`
-	Category    CategoryInfo `json:"category"`
-	Created     string       `json:"created_at"`
+	ID          uint             `json:"id"`
+	Title       string           `json:"title"`
+	Description string           `json:"description"`
+	Author      AuthorInfo       `json:"author"`
+	Authors     []PostAuthorInfo `json:"authors"` // the author first, then the other owners and the co-authors
+	Category    CategoryInfo     `json:"category"`
+	Status      string           `json:"status"`
+	Tags        []string         `json:"tags"`
+	Media       []MediaResponse  `json:"media"`
+
+	Excerpt       string            `json:"excerpt"`      // written by the author or generated from the description
+	WordCount     int               `json:"word_count"`   // Chinese and Japanese characters count as one word each
+	ReadingTime   int               `json:"reading_time"` // estimated minutes
+	FeaturedImage *MediaResponse    `json:"featured_image"`
+	SEO           PostSEOResponse   `json:"seo"`
+	Series        *SeriesInfo       `json:"series"` // null unless the post is part of a series
+	Locale        string            `json:"locale"`
+	Translations  []TranslationInfo `json:"translations"` // published versions of the post in other languages
+
+	Created  string       `json:"created_at"`
+	Comments int64        `json:"comment_count"`
+	Locked   bool         `json:"comments_locked"`
+	Version  uint         `json:"version"`
+	Search   *SearchMatch `json:"search,omitempty"`
+	ReactionSummary
+}
+
+// SeriesInfo places the post within its series. Position and total count the published
+// parts; previous and next are null at either end.
+type SeriesInfo struct {
+	ID       uint        `json:"id"`
+	Title    string      `json:"title"`
+	Position int         `json:"position"`
+	Total    int         `json:"total"`
+	Previous *SeriesLink `json:"previous"`
+	Next     *SeriesLink `json:"next"`
+}
+
+type SeriesLink struct {
+	ID    uint   `json:"id"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+type TranslationInfo struct {
+	ID     uint   `json:"id"`
+	Locale string `json:"locale"`
+	Title  string `json:"title"`
+	URL    string `json:"url"`
+}
+
+// SearchMatch describes why a post matched a full-text search. Matched words are wrapped
+// in <mark> tags; the surrounding text is returned as stored.
+type SearchMatch struct {
+	Rank    float64 `json:"rank"`
+	Title   string  `json:"title"`
+	Snippet string  `json:"snippet"`
+}
+
+type ReactionSummary struct {
+	Reactions   map[string]int64 `json:"reactions"`
+	MyReactions []string         `json:"my_reactions"`
+	LikedByMe   bool             `json:"liked_by_me"`
+}
+
+// ToReactionSummary reports a count for every reaction type, including those nobody used yet
+func ToReactionSummary(counts map[string]int64, mine []string) ReactionSummary {
+	summary := ReactionSummary{
+		Reactions:   make(map[string]int64, len(models.ReactionTypes)),
+		MyReactions: []string{},
+	}
+	for _, reactionType := range models.ReactionTypes {
+		summary.Reactions[reactionType] = counts[reactionType]
+	}
+	for _, reactionType := range mine {
+		summary.MyReactions = append(summary.MyReactions, reactionType)
+		if reactionType == models.ReactionLike {
+			summary.LikedByMe = true
+		}
+	}
+	return summary
+}
+
+// PostSEOResponse is what a page needs for search engines and social previews. Fields the
+// author left empty are filled in from the post: the title, the excerpt and the featured image.
+type PostSEOResponse struct {
+	MetaTitle       string            `json:"meta_title"`
+	MetaDescription string            `json:"meta_description"`
+	CanonicalURL    string            `json:"canonical_url"` // empty when the post's own page is canonical
+	OpenGraph       OpenGraphResponse `json:"open_graph"`
+	Twitter         TwitterResponse   `json:"twitter"`
+}
+
+type OpenGraphResponse struct {
+	Type        string `json:"type"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Image       string `json:"image"`
+	URL         string `json:"url"`
+}
+
+type TwitterResponse struct {
+	Card        string `json:"card"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Image       string `json:"image"`
+}
+
+func toPostSEOResponse(p models.Post, excerpt string) PostSEOResponse {
+	seo := PostSEOResponse{
+		MetaTitle:       firstNonEmpty(p.MetaTitle, p.Title),
+		MetaDescription: firstNonEmpty(p.MetaDescription, excerpt),
+		CanonicalURL:    p.CanonicalURL,
+	}
+
+	image := ""
+	if p.FeaturedMedia != nil {
+		image = p.FeaturedMedia.URL
+	}
+	seo.OpenGraph = OpenGraphResponse{
+		Type:        "article",
+		Title:       firstNonEmpty(p.OGTitle, seo.MetaTitle),
+		Description: firstNonEmpty(p.OGDescription, seo.MetaDescription),
+		Image:       image,
+		URL:         firstNonEmpty(p.CanonicalURL, PostURL(p.ID)),
+	}
+
+	card := p.TwitterCard
+	if card == "" {
+		card = "summary"
+		if image != "" {
+			card = "summary_large_image"
+		}
+	}
+	seo.Twitter = TwitterResponse{
+		Card:        card,
+		Title:       seo.OpenGraph.Title,
+		Description: seo.OpenGraph.Description,
+		Image:       image,
+	}
+	return seo
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
 }
 
 type AuthorInfo struct {
@@ -20,17 +171,31 @@ type AuthorInfo struct {
 	Email string `json:"email"`
 }
 
+// PostAuthorInfo is an author with their role on the post: owner or co-author
+type PostAuthorInfo struct {
+	AuthorInfo
+	Role string `json:"role"`
+}
+
 type CategoryInfo struct {
 	ID   uint   `json:"id"`
 	Name string `json:"name"`
 }
 
 func ToPostResponse(p models.Post) PostResponse {
-	return PostResponse{
+	response := PostResponse{
 		ID:          p.ID,
 		Title:       p.Title,
 		Description: p.Description,
 		Created:     p.CreatedAt.Format(time.RFC3339),
+		Comments:    p.CommentCount,
+		Locked:      p.CommentsLocked,
+		Version:     p.Version,
+		Status:      p.Status,
+		Tags:        []string{},
+		Media:       []MediaResponse{},
+
+		ReactionSummary: ToReactionSummary(p.ReactionCounts, p.ViewerReactions),
 
 		Author: AuthorInfo{
 			ID:    p.Author.ID,
@@ -42,4 +207,74 @@ func ToPostResponse(p models.Post) PostResponse {
 			Name: p.Category.Name,
 		},
 	}
+	response.Authors = toPostAuthors(p)
+	for _, tag := range p.Tags {
+		response.Tags = append(response.Tags, tag.Name)
+	}
+	for _, media := range p.Media {
+		response.Media = append(response.Media, ToMediaResponse(media))
+	}
+	response.Excerpt = firstNonEmpty(p.Excerpt, p.AutoExcerpt)
+	response.WordCount = p.WordCount
+	response.ReadingTime = p.ReadingTime
+	if p.FeaturedMedia != nil {
+		featured := ToMediaResponse(*p.FeaturedMedia)
+		response.FeaturedImage = &featured
+	}
+	response.SEO = toPostSEOResponse(p, response.Excerpt)
+	response.Locale = p.Locale
+	response.Translations = []TranslationInfo{}
+	for _, translation := range p.Translations {
+		response.Translations = append(response.Translations, TranslationInfo{
+			ID:     translation.ID,
+			Locale: translation.Locale,
+			Title:  translation.Title,
+			URL:    PostURL(translation.ID),
+		})
+	}
+	if p.SeriesNav != nil {
+		response.Series = toSeriesInfo(*p.SeriesNav)
+	}
+	if p.TitleHighlight != "" {
+		response.Search = &SearchMatch{Rank: p.SearchRank, Title: p.TitleHighlight, Snippet: p.Snippet}
+	}
+	return response
+}
+
+func toSeriesInfo(nav models.SeriesNav) *SeriesInfo {
+	return &SeriesInfo{
+		ID:       nav.ID,
+		Title:    nav.Title,
+		Position: nav.Position,
+		Total:    nav.Total,
+		Previous: toSeriesLink(nav.Previous),
+		Next:     toSeriesLink(nav.Next),
+	}
+}
+
+func toSeriesLink(part *models.SeriesPart) *SeriesLink {
+	if part == nil {
+		return nil
+	}
+	return &SeriesLink{ID: part.ID, Title: part.Title, URL: PostURL(part.ID)}
+}
+
+// toPostAuthors lists the post author and the collaborators who accepted to be an owner or
+// co-author; reviewers and pending invitations are left out
+func toPostAuthors(p models.Post) []PostAuthorInfo {
+	authors := []PostAuthorInfo{{
+		AuthorInfo: AuthorInfo{ID: p.Author.ID, Name: p.Author.Name, Email: p.Author.Email},
+		Role:       models.CollaboratorRoleOwner,
+	}}
+	for _, role := range []string{models.CollaboratorRoleOwner, models.CollaboratorRoleCoAuthor} {
+		for _, c := range p.Collaborators {
+			if c.Role == role && c.Status == models.CollaboratorStatusAccepted {
+				authors = append(authors, PostAuthorInfo{
+					AuthorInfo: AuthorInfo{ID: c.User.ID, Name: c.User.Name, Email: c.User.Email},
+					Role:       role,
+				})
+			}
+		}
+	}
+	return authors
 }
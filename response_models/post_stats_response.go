@@ -0,0 +1,35 @@
+package responsemodels
+
+import (
+	"crud_api/models"
+	"time"
+)
+
+type DailyViewsResponse struct {
+	Date  string `json:"date"`
+	Views int64  `json:"views"`
+}
+
+type PostStatsResponse struct {
+	PostID     uint                 `json:"post_id"`
+	TotalViews int64                `json:"total_views"`
+	RangeViews int64                `json:"range_views"`
+	From       string               `json:"from"`
+	To         string               `json:"to"`
+	Series     []DailyViewsResponse `json:"series"`
+}
+
+func ToPostStatsResponse(postID uint, totalViews int64, from, to time.Time, series []models.PostDailyViews) PostStatsResponse {
+	resp := PostStatsResponse{
+		PostID:     postID,
+		TotalViews: totalViews,
+		From:       from.Format("2006-01-02"),
+		To:         to.Format("2006-01-02"),
+		Series:     []DailyViewsResponse{},
+	}
+	for _, day := range series {
+		resp.RangeViews += day.Views
+		resp.Series = append(resp.Series, DailyViewsResponse{Date: day.Day.Format("2006-01-02"), Views: day.Views})
+	}
+	return resp
+}
@@ -0,0 +1,56 @@
+package responsemodels
+
+import (
+	"crud_api/models"
+	"time"
+)
+
+type SeriesResponse struct {
+	ID          uint                 `json:"id"`
+	Title       string               `json:"title"`
+	Description string               `json:"description"`
+	OwnerID     uint                 `json:"owner_id"`
+	Posts       []SeriesPostResponse `json:"posts,omitempty"` // in reading order; only in series details
+	Created     string               `json:"created_at"`
+	Updated     string               `json:"updated_at"`
+}
+
+type SeriesPostResponse struct {
+	ID       uint   `json:"id"`
+	Title    string `json:"title"`
+	Excerpt  string `json:"excerpt"`
+	Status   string `json:"status"`
+	Position int    `json:"position"`
+	URL      string `json:"url"`
+	Created  string `json:"created_at"`
+}
+
+func ToSeriesResponse(s models.Series) SeriesResponse {
+	return SeriesResponse{
+		ID:          s.ID,
+		Title:       s.Title,
+		Description: s.Description,
+		OwnerID:     s.OwnerID,
+		Created:     s.CreatedAt.Format(time.RFC3339),
+		Updated:     s.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// ToSeriesDetailsResponse includes the given parts, numbered in the order they are listed
+func ToSeriesDetailsResponse(s models.Series, posts []models.Post) SeriesResponse {
+	response := ToSeriesResponse(s)
+	response.Posts = []SeriesPostResponse{}
+	for i, post := range posts {
+		link := toSeriesLink(&models.SeriesPart{ID: post.ID, Title: post.Title})
+		response.Posts = append(response.Posts, SeriesPostResponse{
+			ID:       post.ID,
+			Title:    post.Title,
+			Excerpt:  firstNonEmpty(post.Excerpt, post.AutoExcerpt),
+			Status:   post.Status,
+			Position: i + 1,
+			URL:      link.URL,
+			Created:  post.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return response
+}
@@ -0,0 +1,24 @@
+package responsemodels
+
+import (
+	"crud_api/models"
+	"time"
+)
+
+type TrashedPostResponse struct {
+	PostResponse
+	DeletedAt string `json:"deleted_at"`
+	PurgeAt   string `json:"purge_at,omitempty"` // when the post will be permanently deleted, if ever
+}
+
+// ToTrashedPostResponse converts a soft-deleted post; retention is how long the trash keeps posts (0 = forever)
+func ToTrashedPostResponse(p models.Post, retention time.Duration) TrashedPostResponse {
+	resp := TrashedPostResponse{
+		PostResponse: ToPostResponse(p),
+		DeletedAt:    p.DeletedAt.Time.Format(time.RFC3339),
+	}
+	if retention > 0 {
+		resp.PurgeAt = p.DeletedAt.Time.Add(retention).Format(time.RFC3339)
+	}
+	return resp
+}
@@ -3,16 +3,20 @@ package responsemodels
 import "crud_api/models"
 
 type UserResponse struct {
-	ID    uint   `json:"id"`
-	Name  string `json:"name"`
-	Email string `json:"email"`
+	ID       uint   `json:"id"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+	Disabled bool   `json:"disabled"`
 }
 
 func ToUserResponse(u models.User) UserResponse {
 	return UserResponse{
-		ID:    u.ID,
-		Name:  u.Name,
-		Email: u.Email,
+		ID:       u.ID,
+		Name:     u.Name,
+		Email:    u.Email,
+		Role:     u.Role,
+		Disabled: u.Disabled,
 	}
 }
 
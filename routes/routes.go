@@ -1,27 +1,50 @@
 package routes
 
 import (
+	"context"
+	"crud_api/config"
 	"crud_api/handlers"
+	imageprocessing "crud_api/image_processing"
 	"crud_api/middleware"
+	"crud_api/models"
 	"crud_api/repositories"
+	responsemodels "crud_api/response_models"
 	"crud_api/services"
+	"crud_api/sitemap"
+	"crud_api/storage"
+	"expvar"
+	"log"
 	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"gorm.io/gorm"
 )
 
-// RegisterRoutes sets up all the routes for the application
-func RegisterRoutes(e *echo.Echo, db *gorm.DB) {
+// RegisterRoutes sets up all the routes for the application. Background workers are
+// started on wg and stop once ctx is cancelled.
+func RegisterRoutes(ctx context.Context, e *echo.Echo, db *gorm.DB, wg *sync.WaitGroup) {
 
 	// Health check
 	e.GET("/", func(c echo.Context) error {
 		return c.String(http.StatusOK, "Welcome to the Blog API!")
 	})
 
+	appCache := config.NewCache()
+
 	// Auth routes
-	userRepo := repositories.NewUserRepository(db)
-	userService := services.NewUserService(userRepo)
+	// Users are looked up on every authenticated request, so they are cached briefly
+	userRepo := repositories.NewCachedUserRepository(repositories.NewUserRepository(db), appCache, config.GetEnvDuration("USER_CACHE_TTL", time.Minute))
+	userService := services.NewUserService(userRepo, config.GetEnvList("ADMIN_EMAILS", nil))
+	// Only admins can grant roles, so the first ones come from the configuration
+	if promoted, err := userService.BootstrapAdmins(); err != nil {
+		log.Printf("WARNING: %v", err)
+	} else if promoted > 0 {
+		log.Printf("INFO: made %d users from ADMIN_EMAILS admins", promoted)
+	}
 	userHandler := handlers.NewUserHandler(userService)
 	jwtMiddleware := middleware.NewJWTMiddleware(userService)
 	protected := e.Group("")
@@ -32,22 +55,224 @@ func RegisterRoutes(e *echo.Echo, db *gorm.DB) {
 
 	// User routes (protected)
 	protected.GET("/v1/users", userHandler.GetAllUsers)
+	protected.PATCH("/v1/users/:id/role", userHandler.UpdateUserRole, middleware.RequireRole(models.RoleAdmin))     // Admin only
+	protected.PATCH("/v1/users/:id/status", userHandler.UpdateUserStatus, middleware.RequireRole(models.RoleAdmin)) // Disable/enable (admin only)
+	protected.DELETE("/v1/users/:id", userHandler.DeleteUser, middleware.RequireRole(models.RoleAdmin))             // Admin only
+
+	// Runtime metrics, including cache hit ratios (admin only)
+	protected.GET("/debug/vars", echo.WrapHandler(expvar.Handler()), middleware.RequireRole(models.RoleAdmin))
 
 	// Post routes
-	postRepo := repositories.NewPostRepository(db)
-	postService := services.NewPostService(postRepo)
-	postHandler := handlers.NewPostHandler(postService)
+	// Post details and listings are served from the application cache; writes invalidate it
+	postRepo := repositories.NewCachedPostRepository(
+		repositories.NewPostRepository(db, config.SearchLanguage()),
+		appCache,
+		config.GetEnvDuration("CACHE_TTL", 30*time.Second),
+	)
+	mediaRepo := repositories.NewMediaRepository(db)
+	postService := services.NewPostService(postRepo, mediaRepo, services.Locales{
+		Supported: config.GetEnvList("POST_LOCALES", []string{"en", "ne"}),
+		Default:   config.GetEnv("POST_DEFAULT_LOCALE", "en"),
+	})
+	reactionRepo := repositories.NewReactionRepository(db)
+	reactionService := services.NewReactionService(reactionRepo, postRepo)
+	viewRepo := repositories.NewViewRepository(db)
+	viewRecorder := services.NewViewRecorder(viewRepo, os.Getenv("VIEW_HASH_SALT"), config.GetEnvDuration("VIEW_FLUSH_INTERVAL", 10*time.Second))
+	postStatsService := services.NewPostStatsService(viewRepo, postRepo)
+	seriesService := services.NewSeriesService(repositories.NewSeriesRepository(db), postRepo)
+	postHandler := handlers.NewPostHandler(postService, reactionService, seriesService, viewRecorder, config.GetEnvBool("POST_REQUIRE_IF_MATCH", false))
+	reactionHandler := handlers.NewReactionHandler(reactionService)
+	postStatsHandler := handlers.NewPostStatsHandler(postStatsService)
 
-	e.GET("/v1/posts", postHandler.GetPosts)        // Public paginated post listingo
-	e.GET("/v1/posts/:id", postHandler.PostDetails) // Public post details by ID
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		viewRecorder.Run(ctx)
+	}()
 
-	protected.POST("/v1/posts", postHandler.CreatePost)                         // Create post
-	protected.PATCH("/v1/posts/:id", postHandler.PostEdit)                      // Update post
-	protected.DELETE("/v1/posts/:id", postHandler.PostDelete)                   // Delete post
-	protected.GET("/v1/authors/:author_id/posts", postHandler.GetPostsbyAuthor) // Posts by specific author
+	// Posts saved before word counts were computed get them once, in the background
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		updated, err := postService.BackfillTextStats(ctx)
+		if err != nil {
+			log.Printf("WARNING: %v", err)
+		}
+		if updated > 0 {
+			log.Printf("INFO: computed reading time of %d posts", updated)
+		}
+	}()
 
-	// Category routes
+	// Public routes still recognise signed-in readers, e.g. for "liked by me"
+	// Cache policies are configurable so a CDN or reverse proxy can serve the blog. Post details default to
+	// revalidating every time (cheap thanks to ETags) so that views keep being counted.
+	postsCache := middleware.CacheControl(config.GetEnv("CACHE_CONTROL_POSTS", "public, max-age=60"))
+	postCache := middleware.CacheControl(config.GetEnv("CACHE_CONTROL_POST", "public, no-cache"))
+	e.GET("/v1/posts", postHandler.GetPosts, postsCache, jwtMiddleware.OptionalMiddleware)                 // Public paginated post listingo
+	e.GET("/v1/posts/:id", postHandler.PostDetails, postCache, jwtMiddleware.OptionalMiddleware)           // Public post details by ID
+	e.GET("/v1/posts/:id/related", postHandler.RelatedPosts, postsCache, jwtMiddleware.OptionalMiddleware) // Public related posts, best first
+
+	protected.POST("/v1/posts", postHandler.CreatePost)                           // Create post
+	protected.PATCH("/v1/posts/:id", postHandler.PostEdit)                        // Update post
+	protected.DELETE("/v1/posts/:id", postHandler.PostDelete)                     // Delete post
+	protected.GET("/v1/authors/:author_id/posts", postHandler.GetPostsbyAuthor)   // Posts by specific author
+	protected.GET("/v1/posts/:id/stats", postStatsHandler.GetPostStats)           // View statistics (author only)
+	protected.POST("/v1/posts/:id/translations", postHandler.LinkTranslation)     // Link a translation (editors of both posts)
+	protected.DELETE("/v1/posts/:id/translations", postHandler.UnlinkTranslation) // Leave the translation group
+
+	// Media routes
+	mediaStorage := config.NewStorage()
+
+	imageProcessor := imageprocessing.Processor{
+		Widths:      config.GetEnvIntList("MEDIA_VARIANT_WIDTHS", []int{320, 640, 1280}),
+		JPEGQuality: config.GetEnvInt("MEDIA_JPEG_QUALITY", 85),
+		MaxPixels:   config.GetEnvInt("MEDIA_MAX_PIXELS", 50000000),
+	}
+
+	// Image variants are rendered by MEDIA_WORKERS background workers; 0 turns that off. Metadata
+	// is removed while uploading either way.
+	var mediaProcessor *services.MediaProcessor
+	if workers := config.GetEnvInt("MEDIA_WORKERS", 2); workers > 0 {
+		mediaProcessor = services.NewMediaProcessor(mediaRepo, mediaStorage, imageProcessor, workers, config.GetEnvInt("MEDIA_QUEUE_SIZE", 1000))
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mediaProcessor.Run(ctx)
+		}()
+	}
+
+	mediaService := services.NewMediaService(mediaRepo, postRepo, mediaStorage, services.MediaConfig{
+		MaxSize:      int64(config.GetEnvInt("MEDIA_MAX_SIZE", 10<<20)),
+		AllowedTypes: config.GetEnvList("MEDIA_ALLOWED_TYPES", []string{"image/jpeg", "image/png", "image/gif", "image/webp"}),
+		Images:       imageProcessor,
+	}, mediaProcessor)
+	mediaHandler := handlers.NewMediaHandler(mediaService)
+	// Leave room for the multipart headers around the file
+	mediaBodyLimit := middleware.BodyLimit(mediaService.MaxSize() + 64<<10)
+
+	e.GET("/v1/media/:id", mediaHandler.GetMedia)                         // Public file details
+	protected.POST("/v1/media", mediaHandler.UploadMedia, mediaBodyLimit) // Upload (multipart, field "file")
+	protected.GET("/v1/users/me/media", mediaHandler.ListMyMedia)         // Own uploads
+	protected.DELETE("/v1/media/:id", mediaHandler.DeleteMedia)           // Delete (owner or admin)
+
+	// Files in local storage are served by the application
+	if local, ok := mediaStorage.(*storage.LocalStorage); ok {
+		e.Group("/uploads", middleware.NoSniff).Static("", local.Root())
+	}
+
+	// Series routes
+	seriesHandler := handlers.NewSeriesHandler(seriesService)
+
+	e.GET("/v1/series/:id", seriesHandler.GetSeries, jwtMiddleware.OptionalMiddleware) // Public series with its published posts
+	protected.POST("/v1/series", seriesHandler.CreateSeries)                           // Create
+	protected.GET("/v1/users/me/series", seriesHandler.ListMySeries)                   // Own series
+	protected.PATCH("/v1/series/:id", seriesHandler.EditSeries)                        // Update (owner only)
+	protected.DELETE("/v1/series/:id", seriesHandler.DeleteSeries)                     // Delete, keeping the posts (owner only)
+	protected.POST("/v1/series/:id/posts", seriesHandler.AddSeriesPost)                // Append own post (owner only)
+	protected.DELETE("/v1/series/:id/posts/:post_id", seriesHandler.RemoveSeriesPost)  // Take post out (owner only)
+	protected.PUT("/v1/series/:id/order", seriesHandler.ReorderSeries)                 // Set reading order (owner only)
+
+	// Collaborator routes (protected)
+	collaboratorService := services.NewCollaboratorService(postRepo, userRepo)
+	collaboratorHandler := handlers.NewCollaboratorHandler(collaboratorService)
+
+	protected.GET("/v1/posts/:id/collaborators", collaboratorHandler.ListCollaborators)              // Collaborators and pending invitations
+	protected.POST("/v1/posts/:id/collaborators", collaboratorHandler.InviteCollaborator)            // Invite (owners only)
+	protected.PATCH("/v1/posts/:id/collaborators/:user_id", collaboratorHandler.EditCollaborator)    // Change role (owners only)
+	protected.DELETE("/v1/posts/:id/collaborators/:user_id", collaboratorHandler.RemoveCollaborator) // Remove (owners, or yourself)
+	protected.GET("/v1/users/me/invitations", collaboratorHandler.ListInvitations)                   // Pending invitations
+	protected.POST("/v1/users/me/invitations/:post_id/accept", collaboratorHandler.AcceptInvitation) // Accept
+	protected.DELETE("/v1/users/me/invitations/:post_id", collaboratorHandler.DeclineInvitation)     // Decline
+
+	// Bulk post routes
 	categoryRepo := repositories.NewCategoryRepository(db)
+	postBulkService := services.NewPostBulkService(postRepo, categoryRepo, config.GetEnvInt("POST_BULK_LIMIT", 500))
+	postBulkHandler := handlers.NewPostBulkHandler(postBulkService)
+
+	protected.POST("/v1/posts/bulk", postBulkHandler.BulkPosts) // Delete, move, change status or tag many posts
+
+	// Trash routes
+	trashService := services.NewTrashService(postRepo, config.GetEnvDuration("TRASH_RETENTION", 30*24*time.Hour))
+	trashHandler := handlers.NewTrashHandler(trashService, reactionService)
+
+	protected.GET("/v1/users/me/trash", trashHandler.ListTrash)                                               // Own deleted posts
+	protected.POST("/v1/posts/:id/restore", trashHandler.RestorePost)                                         // Restore (author or admin)
+	protected.DELETE("/v1/posts/:id/purge", trashHandler.PurgePost, middleware.RequireRole(models.RoleAdmin)) // Permanent delete (admin only)
+
+	if trashService.Retention() > 0 {
+		trashPurger := services.NewTrashPurger(trashService, config.GetEnvDuration("TRASH_PURGE_INTERVAL", time.Hour))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			trashPurger.Run(ctx)
+		}()
+	}
+
+	// Reaction routes
+	protected.PUT("/v1/posts/:id/reactions/:type", reactionHandler.AddReaction)       // React (idempotent)
+	protected.DELETE("/v1/posts/:id/reactions/:type", reactionHandler.RemoveReaction) // Remove reaction
+
+	// Comment routes
+	commentRepo := repositories.NewCommentRepository(db)
+	commentModerator := services.NewCommentModerator(services.CommentModerationConfig{
+		DefaultStatus:    config.GetEnv("COMMENT_DEFAULT_STATUS", models.CommentStatusPending),
+		BlockedWords:     config.GetEnvList("COMMENT_BLOCKED_WORDS", nil),
+		HoldLinks:        config.GetEnvBool("COMMENT_HOLD_LINKS", true),
+		AutoApproveAfter: config.GetEnvInt("COMMENT_AUTO_APPROVE_AFTER", 3),
+	})
+	commentService := services.NewCommentService(commentRepo, postRepo, commentModerator, config.GetEnvDuration("COMMENT_EDIT_WINDOW", 15*time.Minute))
+	commentHandler := handlers.NewCommentHandler(commentService)
+
+	commentsCache := middleware.CacheControl(config.GetEnv("CACHE_CONTROL_COMMENTS", "public, max-age=30"))
+	e.GET("/v1/posts/:id/comments", commentHandler.ListComments, commentsCache, jwtMiddleware.OptionalMiddleware) // Public comments (tree or flat)
+	protected.POST("/v1/posts/:id/comments", commentHandler.CreateComment)                                        // Comment or reply
+	protected.PATCH("/v1/comments/:id", commentHandler.EditComment)                                               // Edit own comment
+	protected.DELETE("/v1/comments/:id", commentHandler.DeleteComment)                                            // Delete own comment
+	protected.PUT("/v1/posts/:id/comments/lock", commentHandler.LockComments)                                     // Lock/unlock (post author or moderator)
+
+	// Moderation routes (moderators and admins)
+	moderation := protected.Group("/v1/moderation", middleware.RequireRole(models.RoleModerator, models.RoleAdmin))
+	moderation.GET("/comments", commentHandler.ModerationQueue)            // Comments by status, pending by default
+	moderation.POST("/comments/bulk", commentHandler.BulkModerateComments) // Approve/reject/spam in bulk
+
+	// Bookmark routes (protected)
+	bookmarkRepo := repositories.NewBookmarkRepository(db)
+	bookmarkService := services.NewBookmarkService(bookmarkRepo, postRepo)
+	bookmarkHandler := handlers.NewBookmarkHandler(bookmarkService, reactionService)
+
+	protected.GET("/v1/users/me/bookmarks", bookmarkHandler.ListBookmarks)               // Paginated reading list
+	protected.GET("/v1/users/me/bookmarks/folders", bookmarkHandler.ListBookmarkFolders) // Folders with counts
+	protected.POST("/v1/users/me/bookmarks/:post_id", bookmarkHandler.AddBookmark)       // Save (or move) bookmark
+	protected.DELETE("/v1/users/me/bookmarks/:post_id", bookmarkHandler.RemoveBookmark)  // Remove bookmark
+
+	// Feed routes
+	site := services.Site{
+		Title:       config.GetEnv("SITE_TITLE", "Go Blog"),
+		Description: config.GetEnv("SITE_DESCRIPTION", ""),
+		URL:         strings.TrimRight(config.GetEnv("SITE_URL", "http://localhost:8000"), "/"),
+	}
+	// Post responses link to the post pages, like the feeds and sitemaps
+	responsemodels.PostURL = site.PostURL
+	feedService := services.NewFeedService(postRepo, categoryRepo, userRepo, site, config.GetEnvInt("FEED_ITEMS", 20))
+	feedHandler := handlers.NewFeedHandler(feedService)
+	feedsCache := middleware.CacheControl(config.GetEnv("CACHE_CONTROL_FEEDS", "public, max-age=300"))
+
+	for _, prefix := range []string{"/feeds", "/feeds/categories/:category_id", "/feeds/authors/:author_id"} {
+		e.GET(prefix+"/rss.xml", feedHandler.RSS, feedsCache)        // RSS 2.0
+		e.GET(prefix+"/atom.xml", feedHandler.Atom, feedsCache)      // Atom 1.0
+		e.GET(prefix+"/feed.json", feedHandler.JSONFeed, feedsCache) // JSON Feed 1.1
+	}
+
+	// Sitemap routes, rebuilt from the database at most once per SITEMAP_TTL
+	sitemapService := services.NewSitemapService(repositories.NewSitemapRepository(db), site, sitemap.MaxURLs, config.GetEnvDuration("SITEMAP_TTL", time.Hour))
+	sitemapHandler := handlers.NewSitemapHandler(sitemapService)
+	sitemapCache := middleware.CacheControl(config.GetEnv("CACHE_CONTROL_SITEMAP", "public, max-age=3600"))
+
+	e.GET("/sitemap.xml", sitemapHandler.Sitemap, sitemapCache)        // Sitemap, or sitemap index above 50k URLs
+	e.GET("/sitemaps/:page", sitemapHandler.SitemapPage, sitemapCache) // Sitemap pages of the index, e.g. /sitemaps/2.xml
+
+	// Category routes
 	categoryService := services.NewCategoryService(categoryRepo)
 	categoryHandler := handlers.NewCategoryHandler(categoryService)
 
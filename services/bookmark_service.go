@@ -0,0 +1,53 @@
+package services
+
+import (
+	"crud_api/models"
+	"crud_api/repositories"
+)
+
+type BookmarkService interface {
+	Save(bookmark *models.Bookmark, user models.User) error
+	Remove(userID, postID uint) error
+	// List returns the user's bookmarks; posts the user may no longer see are left out of them,
+	// so those bookmarks show as unavailable like bookmarks of deleted posts
+	List(user models.User, folder *string, limit, offset int) ([]models.Bookmark, int64, error)
+	Folders(userID uint) ([]repositories.BookmarkFolder, error)
+}
+
+type bookmarkService struct {
+	repo     repositories.BookmarkRepository
+	postRepo repositories.PostRepository
+}
+
+func NewBookmarkService(repo repositories.BookmarkRepository, postRepo repositories.PostRepository) BookmarkService {
+	return &bookmarkService{repo: repo, postRepo: postRepo}
+}
+
+func (s *bookmarkService) Save(bookmark *models.Bookmark, user models.User) error {
+	// Only live posts the user can see can be bookmarked; existing bookmarks survive the post being trashed
+	if _, err := findVisiblePost(s.postRepo, bookmark.PostID, user); err != nil {
+		return err
+	}
+	return s.repo.Save(bookmark)
+}
+
+func (s *bookmarkService) Remove(userID, postID uint) error {
+	return s.repo.Remove(userID, postID)
+}
+
+func (s *bookmarkService) List(user models.User, folder *string, limit, offset int) ([]models.Bookmark, int64, error) {
+	bookmarks, total, err := s.repo.List(user.ID, folder, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range bookmarks {
+		if !CanSeePost(&bookmarks[i].Post, user) {
+			bookmarks[i].Post = models.Post{}
+		}
+	}
+	return bookmarks, total, nil
+}
+
+func (s *bookmarkService) Folders(userID uint) ([]repositories.BookmarkFolder, error) {
+	return s.repo.Folders(userID)
+}
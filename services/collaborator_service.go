@@ -0,0 +1,222 @@
+package services
+
+import (
+	"crud_api/errors"
+	"crud_api/models"
+	"crud_api/repositories"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// maxCollaboratorsPerPost caps the invitations and collaborators of a single post
+const maxCollaboratorsPerPost = 20
+
+type CollaboratorService interface {
+	// List returns the collaborators of the post, pending invitations included. Only users with
+	// a role on the post and staff may see them.
+	List(postID uint, user models.User) ([]models.PostCollaborator, error)
+	// Invite asks the user to join the post with the role; only owners may invite
+	Invite(postID uint, inviter models.User, userID uint, role string) (*models.PostCollaborator, error)
+	ChangeRole(postID, userID uint, role string, actor models.User) (*models.PostCollaborator, error)
+	// Remove takes the user off the post. Owners may remove anyone but the post author; users
+	// may always remove themselves.
+	Remove(postID, userID uint, actor models.User) error
+	Invitations(userID uint) ([]models.PostCollaborator, error)
+	Accept(postID, userID uint) (*models.PostCollaborator, error)
+	Decline(postID, userID uint) error
+}
+
+type collaboratorService struct {
+	postRepo repositories.PostRepository
+	userRepo repositories.UserRepository
+}
+
+func NewCollaboratorService(postRepo repositories.PostRepository, userRepo repositories.UserRepository) CollaboratorService {
+	return &collaboratorService{postRepo: postRepo, userRepo: userRepo}
+}
+
+func (s *collaboratorService) List(postID uint, user models.User) ([]models.PostCollaborator, error) {
+	post, err := s.postRepo.FindByID(postID)
+	if err != nil {
+		return nil, err
+	}
+	if post.RoleOf(user.ID) == "" && !user.IsStaff() {
+		return nil, errors.Forbidden("You are not authorized to see the collaborators of this post", "Tried to list collaborators of unauthorized post")
+	}
+	return post.Collaborators, nil
+}
+
+func (s *collaboratorService) Invite(postID uint, inviter models.User, userID uint, role string) (*models.PostCollaborator, error) {
+	if err := validateCollaboratorRole(role); err != nil {
+		return nil, err
+	}
+
+	post, err := s.postRepo.FindByID(postID)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeCollaboratorChange(post, inviter.ID); err != nil {
+		return nil, err
+	}
+
+	if userID == post.AuthorID {
+		return nil, errors.Conflict("The author of the post is already its owner", fmt.Sprintf("Tried to invite author of post '%d'", post.ID))
+	}
+	if findCollaborator(post, userID) != nil {
+		return nil, errors.Conflict("The user is already invited to this post", fmt.Sprintf("User '%d' already collaborates on post '%d'", userID, post.ID))
+	}
+	if len(post.Collaborators) >= maxCollaboratorsPerPost {
+		return nil, errors.BadRequest(
+			fmt.Sprintf("A post can have at most %d collaborators", maxCollaboratorsPerPost),
+			fmt.Sprintf("Post '%d' has too many collaborators", post.ID),
+		)
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled {
+		return nil, errors.BadRequest("This account has been disabled", fmt.Sprintf("Tried to invite disabled user '%d'", user.ID))
+	}
+
+	collaborator := &models.PostCollaborator{
+		PostID:      post.ID,
+		UserID:      user.ID,
+		User:        *user,
+		Role:        role,
+		Status:      models.CollaboratorStatusInvited,
+		InvitedByID: inviter.ID,
+	}
+	if err := s.postRepo.SaveCollaborator(collaborator); err != nil {
+		return nil, err
+	}
+	return collaborator, nil
+}
+
+func (s *collaboratorService) ChangeRole(postID, userID uint, role string, actor models.User) (*models.PostCollaborator, error) {
+	if err := validateCollaboratorRole(role); err != nil {
+		return nil, err
+	}
+
+	post, err := s.postRepo.FindByID(postID)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeCollaboratorChange(post, actor.ID); err != nil {
+		return nil, err
+	}
+	if userID == post.AuthorID {
+		return nil, errors.BadRequest("The author of the post always stays its owner", fmt.Sprintf("Tried to change role of author of post '%d'", post.ID))
+	}
+
+	collaborator := findCollaborator(post, userID)
+	if collaborator == nil {
+		return nil, collaboratorNotFound(post.ID, userID)
+	}
+	collaborator.Role = role
+	if err := s.postRepo.SaveCollaborator(collaborator); err != nil {
+		return nil, err
+	}
+	return collaborator, nil
+}
+
+func (s *collaboratorService) Remove(postID, userID uint, actor models.User) error {
+	post, err := s.postRepo.FindByID(postID)
+	if err != nil {
+		return err
+	}
+	if userID != actor.ID {
+		if err := authorizeCollaboratorChange(post, actor.ID); err != nil {
+			return err
+		}
+	}
+	if userID == post.AuthorID {
+		return errors.BadRequest("The author of the post can't be removed from it", fmt.Sprintf("Tried to remove author of post '%d'", post.ID))
+	}
+
+	collaborator := findCollaborator(post, userID)
+	if collaborator == nil {
+		return collaboratorNotFound(post.ID, userID)
+	}
+	return s.postRepo.RemoveCollaborator(collaborator)
+}
+
+func (s *collaboratorService) Invitations(userID uint) ([]models.PostCollaborator, error) {
+	return s.postRepo.FindInvitations(userID)
+}
+
+func (s *collaboratorService) Accept(postID, userID uint) (*models.PostCollaborator, error) {
+	post, err := s.postRepo.FindByID(postID)
+	if err != nil {
+		return nil, err
+	}
+
+	collaborator, err := pendingInvitation(post, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	collaborator.Status = models.CollaboratorStatusAccepted
+	collaborator.AcceptedAt = &now
+	if err := s.postRepo.SaveCollaborator(collaborator); err != nil {
+		return nil, err
+	}
+	return collaborator, nil
+}
+
+func (s *collaboratorService) Decline(postID, userID uint) error {
+	post, err := s.postRepo.FindByID(postID)
+	if err != nil {
+		return err
+	}
+
+	collaborator, err := pendingInvitation(post, userID)
+	if err != nil {
+		return err
+	}
+	return s.postRepo.RemoveCollaborator(collaborator)
+}
+
+// authorizeCollaboratorChange holds the rule for who may manage the collaborators of a post: its owners
+func authorizeCollaboratorChange(post *models.Post, userID uint) error {
+	if post.RoleOf(userID) != models.CollaboratorRoleOwner {
+		return errors.Forbidden("Only owners can manage the collaborators of this post", "Tried to manage collaborators of unauthorized post")
+	}
+	return nil
+}
+
+// findCollaborator returns the user's invitation or role on the post, or nil
+func findCollaborator(post *models.Post, userID uint) *models.PostCollaborator {
+	for i := range post.Collaborators {
+		if post.Collaborators[i].UserID == userID {
+			return &post.Collaborators[i]
+		}
+	}
+	return nil
+}
+
+// pendingInvitation returns the user's invitation to the post if they haven't accepted it yet
+func pendingInvitation(post *models.Post, userID uint) (*models.PostCollaborator, error) {
+	collaborator := findCollaborator(post, userID)
+	if collaborator == nil || collaborator.Status != models.CollaboratorStatusInvited {
+		return nil, errors.NotFound("Invitation not found", fmt.Sprintf("User '%d' has no pending invitation to post '%d'", userID, post.ID))
+	}
+	return collaborator, nil
+}
+
+func collaboratorNotFound(postID, userID uint) error {
+	return errors.NotFound("Collaborator not found", fmt.Sprintf("User '%d' is not a collaborator of post '%d'", userID, postID))
+}
+
+func validateCollaboratorRole(role string) error {
+	if !models.IsCollaboratorRole(role) {
+		return errors.BadRequest(
+			"Role must be one of "+strings.Join(models.CollaboratorRoles, ", "),
+			"Client sent unknown collaborator role '"+role+"'",
+		)
+	}
+	return nil
+}
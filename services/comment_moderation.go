@@ -0,0 +1,102 @@
+package services
+
+import (
+	"crud_api/models"
+	"regexp"
+	"strings"
+)
+
+// ModerationInput is what moderation rules look at when a comment is submitted
+type ModerationInput struct {
+	Comment       *models.Comment
+	ApprovedCount int64 // comments by the same author that were approved before
+}
+
+// ModerationRule decides the initial status of a new comment. Evaluate returns
+// the status and true when the rule applies, or false to defer to the next rule.
+type ModerationRule interface {
+	Evaluate(in ModerationInput) (string, bool)
+}
+
+// BlockedWordsRule marks comments containing any of the words as spam
+type BlockedWordsRule struct {
+	Words []string
+}
+
+func (r BlockedWordsRule) Evaluate(in ModerationInput) (string, bool) {
+	body := strings.ToLower(in.Comment.Body)
+	for _, word := range r.Words {
+		if word != "" && strings.Contains(body, strings.ToLower(word)) {
+			return models.CommentStatusSpam, true
+		}
+	}
+	return "", false
+}
+
+var linkPattern = regexp.MustCompile(`(?i)(https?://|www\.|<a\s)`)
+
+// HoldLinksRule sends comments containing links to the moderation queue
+type HoldLinksRule struct{}
+
+func (HoldLinksRule) Evaluate(in ModerationInput) (string, bool) {
+	if linkPattern.MatchString(in.Comment.Body) {
+		return models.CommentStatusPending, true
+	}
+	return "", false
+}
+
+// TrustedAuthorRule approves comments from authors with at least MinApproved approved comments
+type TrustedAuthorRule struct {
+	MinApproved int64
+}
+
+func (r TrustedAuthorRule) Evaluate(in ModerationInput) (string, bool) {
+	if in.ApprovedCount >= r.MinApproved {
+		return models.CommentStatusApproved, true
+	}
+	return "", false
+}
+
+// CommentModerationConfig lists the configurable moderation rules
+type CommentModerationConfig struct {
+	DefaultStatus    string   // status used when no rule applies: pending or approved
+	BlockedWords     []string // comments containing these words are marked as spam
+	HoldLinks        bool     // hold comments containing links for review
+	AutoApproveAfter int      // auto-approve authors with this many approved comments; 0 disables
+}
+
+// CommentModerator evaluates rules in order; the first rule that applies wins
+type CommentModerator struct {
+	rules         []ModerationRule
+	defaultStatus string
+}
+
+// NewCommentModerator builds the rule chain: blocked words, then links, then trusted authors
+func NewCommentModerator(cfg CommentModerationConfig) *CommentModerator {
+	var rules []ModerationRule
+	if len(cfg.BlockedWords) > 0 {
+		rules = append(rules, BlockedWordsRule{Words: cfg.BlockedWords})
+	}
+	if cfg.HoldLinks {
+		rules = append(rules, HoldLinksRule{})
+	}
+	if cfg.AutoApproveAfter > 0 {
+		rules = append(rules, TrustedAuthorRule{MinApproved: int64(cfg.AutoApproveAfter)})
+	}
+
+	defaultStatus := cfg.DefaultStatus
+	if defaultStatus != models.CommentStatusApproved {
+		defaultStatus = models.CommentStatusPending
+	}
+	return &CommentModerator{rules: rules, defaultStatus: defaultStatus}
+}
+
+// Decide returns the status a newly submitted comment should start with
+func (m *CommentModerator) Decide(in ModerationInput) string {
+	for _, rule := range m.rules {
+		if status, ok := rule.Evaluate(in); ok {
+			return status
+		}
+	}
+	return m.defaultStatus
+}
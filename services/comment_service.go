@@ -0,0 +1,253 @@
+package services
+
+import (
+	"crud_api/errors"
+	"crud_api/models"
+	"crud_api/repositories"
+	"time"
+)
+
+type CommentService interface {
+	Create(comment *models.Comment, user models.User) error
+	GetByID(id uint) (*models.Comment, error)
+	ListFlat(postID uint, viewer models.User, after *repositories.Cursor, limit int) ([]models.Comment, *repositories.Cursor, error)
+	ListThreads(postID uint, viewer models.User, after *repositories.Cursor, limit int) ([]models.Comment, *repositories.Cursor, error)
+	Update(comment *models.Comment, userID uint) error
+	Delete(comment *models.Comment, userID uint) error
+	ModerationQueue(status string, limit, offset int) ([]models.Comment, int64, error)
+	Moderate(ids []uint, status string) (int64, error)
+	SetCommentsLocked(postID uint, locked bool, user models.User) error
+}
+
+type commentService struct {
+	repo       repositories.CommentRepository
+	postRepo   repositories.PostRepository
+	moderator  *CommentModerator
+	editWindow time.Duration
+}
+
+// NewCommentService creates a CommentService; authors may edit or delete their comments for editWindow after posting
+func NewCommentService(repo repositories.CommentRepository, postRepo repositories.PostRepository, moderator *CommentModerator, editWindow time.Duration) CommentService {
+	return &commentService{repo: repo, postRepo: postRepo, moderator: moderator, editWindow: editWindow}
+}
+
+func (s *commentService) Create(comment *models.Comment, user models.User) error {
+	// Make sure the post exists, is not soft-deleted and the user can see it
+	post, err := findVisiblePost(s.postRepo, comment.PostID, user)
+	if err != nil {
+		return err
+	}
+	if post.CommentsLocked {
+		return errors.Forbidden("Comments are locked on this post", "Tried to comment on a locked post")
+	}
+
+	if comment.ParentID != nil {
+		parent, err := s.repo.FindByID(*comment.ParentID)
+		if err != nil {
+			return err
+		}
+		if parent.PostID != comment.PostID {
+			return errors.BadRequest("Parent comment belongs to a different post", "Tried to reply to a comment from another post")
+		}
+		if parent.Status != models.CommentStatusApproved {
+			return errors.BadRequest("Cannot reply to this comment", "Tried to reply to a comment that is not visible")
+		}
+
+		rootID := parent.ID
+		if parent.RootID != nil {
+			rootID = *parent.RootID
+		}
+		comment.RootID = &rootID
+	}
+
+	status, err := s.moderationStatus(comment)
+	if err != nil {
+		return err
+	}
+	comment.Status = status
+	return s.repo.Create(comment)
+}
+
+func (s *commentService) GetByID(id uint) (*models.Comment, error) {
+	return s.repo.FindByID(id)
+}
+
+func (s *commentService) ListFlat(postID uint, viewer models.User, after *repositories.Cursor, limit int) ([]models.Comment, *repositories.Cursor, error) {
+	if _, err := findVisiblePost(s.postRepo, postID, viewer); err != nil {
+		return nil, nil, err
+	}
+
+	// Fetch one extra row to know whether another page exists
+	comments, err := s.repo.ListByPost(postID, after, limit+1)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	comments, next := trimCommentPage(comments, limit)
+	return comments, next, nil
+}
+
+func (s *commentService) ListThreads(postID uint, viewer models.User, after *repositories.Cursor, limit int) ([]models.Comment, *repositories.Cursor, error) {
+	if _, err := findVisiblePost(s.postRepo, postID, viewer); err != nil {
+		return nil, nil, err
+	}
+
+	roots, err := s.repo.ListRootsByPost(postID, after, limit+1)
+	if err != nil {
+		return nil, nil, err
+	}
+	roots, next := trimCommentPage(roots, limit)
+
+	rootIDs := make([]uint, 0, len(roots))
+	for _, root := range roots {
+		rootIDs = append(rootIDs, root.ID)
+	}
+
+	replies, err := s.repo.ListByRoots(rootIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return buildCommentTree(roots, replies), next, nil
+}
+
+func (s *commentService) Update(comment *models.Comment, userID uint) error {
+	if err := s.checkOwnEditable(comment, userID, "edit"); err != nil {
+		return err
+	}
+	if comment.Status == models.CommentStatusSpam || comment.Status == models.CommentStatusRejected {
+		return errors.Forbidden("This comment was removed by a moderator", "Tried to edit a rejected comment")
+	}
+
+	// Edits go through the rules again so an approved comment can't be used to sneak in links
+	status, err := s.moderationStatus(comment)
+	if err != nil {
+		return err
+	}
+	comment.Status = status
+	return s.repo.Update(comment)
+}
+
+func (s *commentService) Delete(comment *models.Comment, userID uint) error {
+	if err := s.checkOwnEditable(comment, userID, "delete"); err != nil {
+		return err
+	}
+
+	hasReplies, err := s.repo.HasReplies(comment.ID)
+	if err != nil {
+		return err
+	}
+	if !hasReplies {
+		return s.repo.Delete(comment)
+	}
+
+	// Keep a placeholder so the replies remain part of the thread
+	comment.Body = ""
+	comment.Status = models.CommentStatusDeleted
+	return s.repo.Update(comment)
+}
+
+func (s *commentService) ModerationQueue(status string, limit, offset int) ([]models.Comment, int64, error) {
+	if !isModerationStatus(status) {
+		return nil, 0, errors.BadRequest("Unknown comment status", "Client requested moderation queue for status '"+status+"'")
+	}
+	return s.repo.ListByStatus(status, limit, offset)
+}
+
+func (s *commentService) Moderate(ids []uint, status string) (int64, error) {
+	if !isModerationStatus(status) {
+		return 0, errors.BadRequest("Unknown comment status", "Client tried to moderate comments to status '"+status+"'")
+	}
+	if len(ids) == 0 {
+		return 0, errors.BadRequest("At least one comment ID is required", "Client sent empty moderation batch")
+	}
+	return s.repo.UpdateStatus(ids, status)
+}
+
+func (s *commentService) SetCommentsLocked(postID uint, locked bool, user models.User) error {
+	post, err := s.postRepo.FindByID(postID)
+	if err != nil {
+		return err
+	}
+	if !isPostWriter(post, user.ID) && !user.IsStaff() {
+		return errors.Forbidden("You are not authorized to lock comments on this post", "Tried to lock comments on unauthorized post")
+	}
+	return s.postRepo.SetCommentsLocked(post.ID, locked)
+}
+
+func (s *commentService) moderationStatus(comment *models.Comment) (string, error) {
+	approved, err := s.repo.CountApprovedByAuthor(comment.AuthorID)
+	if err != nil {
+		return "", err
+	}
+	return s.moderator.Decide(ModerationInput{Comment: comment, ApprovedCount: approved}), nil
+}
+
+func isModerationStatus(status string) bool {
+	switch status {
+	case models.CommentStatusPending, models.CommentStatusApproved, models.CommentStatusSpam, models.CommentStatusRejected:
+		return true
+	}
+	return false
+}
+
+func (s *commentService) checkOwnEditable(comment *models.Comment, userID uint, action string) error {
+	if comment.AuthorID != userID {
+		return errors.Forbidden("You are not authorized to "+action+" this comment", "Tried to "+action+" unauthorized comment")
+	}
+	if comment.Status == models.CommentStatusDeleted {
+		return errors.NotFound("Comment not found", "Tried to "+action+" a deleted comment")
+	}
+	if time.Since(comment.CreatedAt) > s.editWindow {
+		return errors.Forbidden("The time allowed to "+action+" this comment has passed", "Tried to "+action+" comment after the edit window")
+	}
+	return nil
+}
+
+// trimCommentPage drops the look-ahead row and returns the cursor for the next page, if any
+func trimCommentPage(comments []models.Comment, limit int) ([]models.Comment, *repositories.Cursor) {
+	if len(comments) <= limit {
+		return comments, nil
+	}
+	comments = comments[:limit]
+	last := comments[len(comments)-1]
+	return comments, repositories.NewCursor(last.CreatedAt, last.ID)
+}
+
+// buildCommentTree nests replies under their parents; replies whose parent is no longer
+// visible are attached directly to the thread root
+func buildCommentTree(roots, replies []models.Comment) []models.Comment {
+	children := make(map[uint][]uint)
+	byID := make(map[uint]*models.Comment, len(roots)+len(replies))
+	for i := range roots {
+		byID[roots[i].ID] = &roots[i]
+	}
+	for i := range replies {
+		byID[replies[i].ID] = &replies[i]
+	}
+
+	for _, reply := range replies {
+		parentID := *reply.RootID
+		if reply.ParentID != nil {
+			if _, ok := byID[*reply.ParentID]; ok {
+				parentID = *reply.ParentID
+			}
+		}
+		children[parentID] = append(children[parentID], reply.ID)
+	}
+
+	var attach func(id uint) models.Comment
+	attach = func(id uint) models.Comment {
+		node := *byID[id]
+		for _, childID := range children[id] {
+			node.Replies = append(node.Replies, attach(childID))
+		}
+		return node
+	}
+
+	tree := make([]models.Comment, 0, len(roots))
+	for _, root := range roots {
+		tree = append(tree, attach(root.ID))
+	}
+	return tree
+}
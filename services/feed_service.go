@@ -0,0 +1,97 @@
+package services
+
+import (
+	"crud_api/feed"
+	listquery "crud_api/list_query"
+	"crud_api/models"
+	"crud_api/repositories"
+)
+
+// FeedScope narrows a feed down to one category or one author; the zero value covers the whole blog
+type FeedScope struct {
+	CategoryID uint
+	AuthorID   uint
+}
+
+type FeedService interface {
+	// Build returns the newest published posts in scope; feedPath is where the feed is served,
+	// relative to the site URL
+	Build(scope FeedScope, feedPath string) (*feed.Feed, error)
+}
+
+type feedService struct {
+	posts      repositories.PostRepository
+	categories repositories.CategoryRepository
+	users      repositories.UserRepository
+	site       Site
+	items      int
+}
+
+// NewFeedService creates a FeedService listing up to items posts per feed
+func NewFeedService(posts repositories.PostRepository, categories repositories.CategoryRepository, users repositories.UserRepository, site Site, items int) FeedService {
+	if items <= 0 {
+		items = 20
+	}
+	return &feedService{posts: posts, categories: categories, users: users, site: site, items: items}
+}
+
+func (s *feedService) Build(scope FeedScope, feedPath string) (*feed.Feed, error) {
+	result := &feed.Feed{
+		Title:       s.site.Title,
+		Description: s.site.Description,
+		SiteURL:     s.site.URL,
+		FeedURL:     s.site.URL + feedPath,
+	}
+	filter := repositories.PostFilter{
+		Statuses: []string{models.PostStatusPublished},
+		Sort:     []listquery.SortField{{Field: "created_at", Desc: true}},
+	}
+
+	if scope.CategoryID != 0 {
+		category, err := s.categories.FindByID(scope.CategoryID)
+		if err != nil {
+			return nil, err
+		}
+		result.Title = s.site.Title + " – " + category.Name
+		result.SiteURL = s.site.CategoryURL(category.ID)
+		filter.CategoryIDs = []int{int(category.ID)}
+	}
+	if scope.AuthorID != 0 {
+		author, err := s.users.FindByID(scope.AuthorID)
+		if err != nil {
+			return nil, err
+		}
+		result.Title = s.site.Title + " – " + author.Name
+		result.SiteURL = s.site.AuthorURL(author.ID)
+		filter.AuthorIDs = []int{int(author.ID)}
+	}
+
+	posts, _, err := s.posts.FindAll(filter, 0, s.items)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, post := range posts {
+		tags := []string{}
+		if post.Category.Name != "" {
+			tags = append(tags, post.Category.Name)
+		}
+		for _, tag := range post.Tags {
+			tags = append(tags, tag.Name)
+		}
+
+		url := s.site.PostURL(post.ID)
+		result.Items = append(result.Items, feed.Item{
+			ID:        url,
+			Title:     post.Title,
+			URL:       url,
+			Content:   post.Description,
+			Author:    post.Author.Name,
+			Tags:      tags,
+			Published: post.CreatedAt,
+			Updated:   post.UpdatedAt,
+		})
+	}
+	result.Updated = feed.LastUpdated(result.Items)
+	return result, nil
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crud_api/errors"
+	imageprocessing "crud_api/image_processing"
+	"crud_api/models"
+	"crud_api/repositories"
+	"crud_api/storage"
+	"io"
+	"log"
+	"path"
+	"strings"
+	"sync"
+)
+
+// MediaProcessor renders the variants of uploaded images on a pool of background workers, so
+// uploads return as soon as the file is stored. Uploads are stored without metadata already;
+// the processor still strips files uploaded before that.
+type MediaProcessor struct {
+	repo      repositories.MediaRepository
+	storage   storage.Storage
+	processor imageprocessing.Processor
+	workers   int
+	queue     chan uint
+}
+
+func NewMediaProcessor(repo repositories.MediaRepository, store storage.Storage, processor imageprocessing.Processor, workers, queueSize int) *MediaProcessor {
+	if workers <= 0 {
+		workers = 1
+	}
+	return &MediaProcessor{
+		repo:      repo,
+		storage:   store,
+		processor: processor,
+		workers:   workers,
+		queue:     make(chan uint, queueSize),
+	}
+}
+
+// Handles reports whether uploads of the content type are processed
+func (p *MediaProcessor) Handles(contentType string) bool {
+	return imageprocessing.Supported(contentType)
+}
+
+// Enqueue schedules processing without blocking. When the queue is full the media stays in
+// the processing state and is picked up again the next time the processor starts.
+func (p *MediaProcessor) Enqueue(id uint) {
+	select {
+	case p.queue <- id:
+	default:
+		log.Printf("WARNING: media processing queue full, media %d will be processed after a restart", id)
+	}
+}
+
+// Run queues the media left unprocessed by a previous run and processes the queue until ctx
+// is cancelled; images being processed at that point are finished on the next start
+func (p *MediaProcessor) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < p.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case id := <-p.queue:
+					p.process(ctx, id)
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	ids, err := p.repo.ProcessingIDs()
+	if err != nil {
+		log.Printf("WARNING: %v", err)
+	}
+	for _, id := range ids {
+		select {
+		case p.queue <- id:
+		case <-ctx.Done():
+		}
+	}
+
+	wg.Wait()
+}
+
+func (p *MediaProcessor) process(ctx context.Context, id uint) {
+	media, err := p.repo.FindByID(id)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppErrors); !ok || appErr.Code != 404 {
+			log.Printf("WARNING: loading media %d for processing: %v", id, err)
+		}
+		return
+	}
+	if media.Status != models.MediaStatusProcessing {
+		return
+	}
+
+	if err := p.render(ctx, media); err != nil {
+		if ctx.Err() != nil {
+			return // shutting down; retried on the next start
+		}
+		log.Printf("WARNING: processing media %d failed: %v", id, err)
+		if err := p.repo.SetStatus(id, models.MediaStatusFailed); err != nil {
+			log.Printf("WARNING: %v", err)
+		}
+	}
+}
+
+func (p *MediaProcessor) render(ctx context.Context, media *models.Media) error {
+	file, err := p.storage.Open(ctx, media.StorageKey)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(file)
+	file.Close()
+	if err != nil {
+		return err
+	}
+
+	result, err := p.processor.Process(data, media.ContentType)
+	if err != nil {
+		return err
+	}
+
+	// Variants are stored next to the original: media/1/ab12.jpg -> media/1/ab12/640w.jpg
+	base := strings.TrimSuffix(media.StorageKey, path.Ext(media.StorageKey))
+	variants := make([]models.MediaVariant, 0, len(result.Variants))
+	for _, rendition := range result.Variants {
+		key := base + "/" + rendition.Name
+		if err := p.storage.Put(ctx, key, bytes.NewReader(rendition.Data), int64(len(rendition.Data)), rendition.ContentType); err != nil {
+			p.deleteFiles(variantKeys(variants)...)
+			return err
+		}
+		variants = append(variants, models.MediaVariant{
+			MediaID:     media.ID,
+			Name:        rendition.Name,
+			StorageKey:  key,
+			URL:         p.storage.URL(key),
+			ContentType: rendition.ContentType,
+			Width:       rendition.Width,
+			Height:      rendition.Height,
+			Size:        int64(len(rendition.Data)),
+		})
+	}
+
+	if result.Original != nil {
+		if err := p.storage.Put(ctx, media.StorageKey, bytes.NewReader(result.Original), int64(len(result.Original)), media.ContentType); err != nil {
+			p.deleteFiles(variantKeys(variants)...)
+			return err
+		}
+		media.Size = int64(len(result.Original))
+	}
+
+	media.Width, media.Height = result.Width, result.Height
+	media.Status = models.MediaStatusReady
+	if err := p.repo.SaveProcessed(media, variants); err != nil {
+		p.deleteFiles(variantKeys(variants)...)
+		if appErr, ok := err.(*errors.AppErrors); ok && appErr.Code == 404 {
+			// Deleted in the meantime, possibly before the original was rewritten
+			p.deleteFiles(media.StorageKey)
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// deleteFiles removes stored files that no media record points at
+func (p *MediaProcessor) deleteFiles(keys ...string) {
+	for _, key := range keys {
+		if err := p.storage.Delete(context.Background(), key); err != nil {
+			log.Printf("WARNING: failed to remove file %s: %v", key, err)
+		}
+	}
+}
+
+func variantKeys(variants []models.MediaVariant) []string {
+	keys := make([]string, 0, len(variants))
+	for _, variant := range variants {
+		keys = append(keys, variant.StorageKey)
+	}
+	return keys
+}
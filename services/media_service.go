@@ -0,0 +1,194 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crud_api/errors"
+	imageprocessing "crud_api/image_processing"
+	"crud_api/models"
+	"crud_api/repositories"
+	"crud_api/storage"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// sniffLength is how much of an upload is inspected to detect its type
+const sniffLength = 3072
+
+// MediaConfig limits what users may upload
+type MediaConfig struct {
+	MaxSize      int64    // largest accepted file in bytes
+	AllowedTypes []string // accepted MIME types, as detected from the file contents
+
+	// Removes the metadata of uploaded images, such as GPS positions, before they are stored
+	Images imageprocessing.Processor
+}
+
+type MediaService interface {
+	// Upload stores the file for the owner. The type is detected from the contents; the
+	// client's Content-Type and file extension are ignored. Images are stored without their
+	// metadata, turned upright.
+	Upload(ctx context.Context, ownerID uint, filename string, size int64, body io.Reader) (*models.Media, error)
+	GetByID(id uint) (*models.Media, error)
+	List(ownerID uint, limit, offset int) ([]models.Media, int64, error)
+	Delete(ctx context.Context, media *models.Media, user models.User) error
+	MaxSize() int64
+}
+
+type mediaService struct {
+	repo      repositories.MediaRepository
+	posts     repositories.PostRepository
+	storage   storage.Storage
+	cfg       MediaConfig
+	processor *MediaProcessor
+}
+
+// NewMediaService creates a MediaService; uploaded images are handed to processor, unless it is nil.
+// Cached posts are invalidated through posts when media attached to them is deleted.
+func NewMediaService(repo repositories.MediaRepository, posts repositories.PostRepository, store storage.Storage, cfg MediaConfig, processor *MediaProcessor) MediaService {
+	return &mediaService{repo: repo, posts: posts, storage: store, cfg: cfg, processor: processor}
+}
+
+func (s *mediaService) Upload(ctx context.Context, ownerID uint, filename string, size int64, body io.Reader) (*models.Media, error) {
+	if size > s.cfg.MaxSize {
+		return nil, errors.PayloadTooLarge(
+			fmt.Sprintf("Files may be at most %d bytes", s.cfg.MaxSize),
+			fmt.Sprintf("Client uploaded %d bytes", size),
+		)
+	}
+	if size == 0 {
+		return nil, errors.BadRequest("The file is empty", "Client uploaded an empty file")
+	}
+
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(body, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, errors.BadRequest("Unable to read the uploaded file", "Failed to read upload for type detection", err)
+	}
+	head = head[:n]
+
+	detected := mimetype.Detect(head)
+	contentType, ok := s.allowedType(detected)
+	if !ok {
+		return nil, errors.UnsupportedMediaType(
+			"File type not allowed; accepted types are "+strings.Join(s.cfg.AllowedTypes, ", "),
+			"Client uploaded a file detected as '"+detected.String()+"'",
+		)
+	}
+
+	key, err := mediaKey(ownerID, detected.Extension())
+	if err != nil {
+		return nil, errors.Internal("Unable to save upload", "Failed to generate media key", err)
+	}
+	// Files are public as soon as they are stored, so images lose their metadata first rather
+	// than waiting for the background processing
+	content := io.MultiReader(bytes.NewReader(head), body)
+	if imageprocessing.Supported(contentType) {
+		data, err := io.ReadAll(io.LimitReader(content, s.cfg.MaxSize+1))
+		if err != nil {
+			return nil, errors.BadRequest("Unable to read the uploaded file", "Failed to read image upload", err)
+		}
+		if data, err = s.cfg.Images.Sanitize(data, contentType); err != nil {
+			return nil, errors.BadRequest("The image could not be read", "Failed to remove image metadata", err)
+		}
+		content, size = bytes.NewReader(data), int64(len(data))
+	}
+	if err := s.storage.Put(ctx, key, content, size, contentType); err != nil {
+		return nil, errors.Internal("Unable to save upload", "Storage error while writing media", err)
+	}
+
+	media := &models.Media{
+		OwnerID:     ownerID,
+		StorageKey:  key,
+		URL:         s.storage.URL(key),
+		Filename:    cleanFilename(filename),
+		ContentType: contentType,
+		Size:        size,
+		Status:      models.MediaStatusReady,
+	}
+	processed := s.processor != nil && s.processor.Handles(contentType)
+	if processed {
+		media.Status = models.MediaStatusProcessing
+	}
+	if err := s.repo.Create(media); err != nil {
+		// Don't leave the file behind without a record pointing at it
+		if delErr := s.storage.Delete(context.Background(), key); delErr != nil {
+			log.Printf("WARNING: failed to remove orphaned upload %s: %v", key, delErr)
+		}
+		return nil, err
+	}
+	if processed {
+		s.processor.Enqueue(media.ID)
+	}
+	return media, nil
+}
+
+func (s *mediaService) GetByID(id uint) (*models.Media, error) {
+	return s.repo.FindByID(id)
+}
+
+func (s *mediaService) List(ownerID uint, limit, offset int) ([]models.Media, int64, error) {
+	return s.repo.ListByOwner(ownerID, limit, offset)
+}
+
+// Delete removes an upload (only by its owner or an admin); posts using it lose the attachment
+func (s *mediaService) Delete(ctx context.Context, media *models.Media, user models.User) error {
+	if media.OwnerID != user.ID && user.Role != models.RoleAdmin {
+		return errors.Forbidden("You are not authorized to delete this file", "Tried to delete unauthorized media")
+	}
+	if err := s.repo.Delete(media); err != nil {
+		return err
+	}
+	s.posts.Invalidate()
+	// The record is gone either way; a file left behind is only wasted space
+	for _, key := range append(variantKeys(media.Variants), media.StorageKey) {
+		if err := s.storage.Delete(ctx, key); err != nil {
+			log.Printf("WARNING: failed to remove file %s of deleted media %d: %v", key, media.ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *mediaService) MaxSize() int64 {
+	return s.cfg.MaxSize
+}
+
+// allowedType returns the configured type the detected one matches, aliases included
+func (s *mediaService) allowedType(detected *mimetype.MIME) (string, bool) {
+	for _, allowed := range s.cfg.AllowedTypes {
+		if detected.Is(allowed) {
+			return allowed, true
+		}
+	}
+	return "", false
+}
+
+// mediaKey returns an unguessable storage key below the owner's directory
+func mediaKey(ownerID uint, extension string) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("media/%d/%s%s", ownerID, hex.EncodeToString(random), extension), nil
+}
+
+// cleanFilename keeps the base name the client sent, for display only
+func cleanFilename(name string) string {
+	name = path.Base(strings.ReplaceAll(strings.TrimSpace(name), "\\", "/"))
+	if name == "." || name == "/" || !utf8.ValidString(name) {
+		return "upload"
+	}
+	for utf8.RuneCountInString(name) > 255 {
+		_, last := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-last]
+	}
+	return name
+}
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"crud_api/errors"
+	"crud_api/models"
+	"crud_api/repositories"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Actions accepted by bulk post operations
+const (
+	BulkPostDelete       = "delete"
+	BulkPostMoveCategory = "move_category"
+	BulkPostSetStatus    = "set_status"
+	BulkPostAddTags      = "add_tags"
+)
+
+const (
+	maxTagLength   = 50
+	maxTagsPerPost = 20
+)
+
+// BulkPostOperation is one action applied to many posts, selected either by ID or by a filter
+type BulkPostOperation struct {
+	Action string
+	IDs    []uint
+	Filter *repositories.PostFilter
+
+	CategoryID uint     // move_category
+	Status     string   // set_status
+	Tags       []string // add_tags
+}
+
+// BulkPostResult is the outcome for one post; Err is nil when the action was applied
+type BulkPostResult struct {
+	ID  uint
+	Err *errors.AppErrors
+}
+
+type PostBulkService interface {
+	Apply(op BulkPostOperation, user models.User) ([]BulkPostResult, error)
+}
+
+type postBulkService struct {
+	repo       repositories.PostRepository
+	categories repositories.CategoryRepository
+	maxPosts   int
+}
+
+// NewPostBulkService creates a PostBulkService acting on at most maxPosts posts per operation
+func NewPostBulkService(repo repositories.PostRepository, categories repositories.CategoryRepository, maxPosts int) PostBulkService {
+	if maxPosts <= 0 {
+		maxPosts = 500
+	}
+	return &postBulkService{repo: repo, categories: categories, maxPosts: maxPosts}
+}
+
+// Apply runs the action on every selected post inside one transaction. Posts the user may not
+// change, or that no longer exist, are reported and skipped; a database error rolls back the
+// whole operation.
+func (s *postBulkService) Apply(op BulkPostOperation, user models.User) ([]BulkPostResult, error) {
+	if err := s.validate(&op); err != nil {
+		return nil, err
+	}
+
+	ids, err := s.targets(op, user)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []BulkPostResult
+	err = s.repo.Transaction(func(tx repositories.PostRepository) error {
+		results = make([]BulkPostResult, 0, len(ids))
+		for _, id := range ids {
+			err := applyBulkAction(tx, op, id, user)
+			if appErr, ok := err.(*errors.AppErrors); ok && appErr.Code < 500 {
+				results = append(results, BulkPostResult{ID: id, Err: appErr})
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			results = append(results, BulkPostResult{ID: id})
+		}
+		return nil
+	})
+	if err != nil {
+		if _, ok := err.(*errors.AppErrors); ok {
+			return nil, err
+		}
+		return nil, errors.Internal("Unable to apply bulk operation", "Database error while committing bulk post operation", err)
+	}
+	return results, nil
+}
+
+func applyBulkAction(tx repositories.PostRepository, op BulkPostOperation, id uint, user models.User) error {
+	post, err := tx.FindByID(id)
+	if err != nil {
+		return err
+	}
+
+	switch op.Action {
+	case BulkPostDelete:
+		if err := authorizePostChange(post, user.ID, "delete"); err != nil {
+			return err
+		}
+		return tx.Delete(post)
+	case BulkPostMoveCategory:
+		if err := authorizePostChange(post, user.ID, "edit"); err != nil {
+			return err
+		}
+		post.CategoryID = op.CategoryID
+		return tx.Update(post)
+	case BulkPostSetStatus:
+		if err := authorizePostChange(post, user.ID, "edit"); err != nil {
+			return err
+		}
+		post.Status = op.Status
+		return tx.Update(post)
+	case BulkPostAddTags:
+		if err := authorizePostChange(post, user.ID, "edit"); err != nil {
+			return err
+		}
+		if len(mergeTags(post.Tags, op.Tags)) > maxTagsPerPost {
+			return errors.BadRequest(
+				fmt.Sprintf("A post can have at most %d tags", maxTagsPerPost),
+				fmt.Sprintf("Tagging post '%d' would exceed the tag limit", id),
+			)
+		}
+		return tx.AddTags(post, op.Tags)
+	}
+	return nil
+}
+
+// validate checks the action and its arguments before any post is touched
+func (s *postBulkService) validate(op *BulkPostOperation) error {
+	switch op.Action {
+	case BulkPostDelete:
+	case BulkPostMoveCategory:
+		if op.CategoryID == 0 {
+			return errors.BadRequest("category_id is required to move posts", "Client sent move_category without a category")
+		}
+		if _, err := s.categories.FindByID(op.CategoryID); err != nil {
+			if appErr, ok := err.(*errors.AppErrors); ok && appErr.Code == 404 {
+				return errors.BadRequest("Category not found", fmt.Sprintf("Client tried to move posts to unknown category '%d'", op.CategoryID))
+			}
+			return err
+		}
+	case BulkPostSetStatus:
+		if !models.IsPostStatus(op.Status) {
+			return errors.BadRequest("Status must be one of draft, published or archived", "Client sent unknown post status '"+op.Status+"'")
+		}
+	case BulkPostAddTags:
+		tags, err := NormalizeTags(op.Tags)
+		if err != nil {
+			return err
+		}
+		if len(tags) == 0 {
+			return errors.BadRequest("At least one tag is required", "Client sent add_tags without tags")
+		}
+		op.Tags = tags
+	default:
+		return errors.BadRequest(
+			"Action must be one of delete, move_category, set_status or add_tags",
+			"Client sent unknown bulk action '"+op.Action+"'",
+		)
+	}
+	return nil
+}
+
+// targets resolves the posts an operation applies to. A filter only selects posts the user may
+// change, so it can't reveal other users' drafts; listed IDs are reported one by one.
+func (s *postBulkService) targets(op BulkPostOperation, user models.User) ([]uint, error) {
+	if (len(op.IDs) > 0) == (op.Filter != nil) {
+		return nil, errors.BadRequest("Select posts either by ids or by filter", "Client sent both or neither of ids and filter")
+	}
+	tooMany := errors.BadRequest(
+		fmt.Sprintf("At most %d posts can be changed at once", s.maxPosts),
+		"Client sent an oversized bulk post operation",
+	)
+
+	if op.Filter == nil {
+		seen := make(map[uint]bool, len(op.IDs))
+		ids := make([]uint, 0, len(op.IDs))
+		for _, id := range op.IDs {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) > s.maxPosts {
+			return nil, tooMany
+		}
+		return ids, nil
+	}
+
+	filter := *op.Filter
+	if filter.Search == "" && len(filter.CategoryIDs) == 0 && len(filter.AuthorIDs) == 0 && len(filter.Statuses) == 0 &&
+		filter.CreatedAfter == nil && filter.CreatedBefore == nil && filter.UpdatedAfter == nil && filter.UpdatedBefore == nil {
+		return nil, errors.BadRequest("The filter needs at least one condition", "Client sent a bulk operation with an empty filter")
+	}
+	if err := validatePostFilter(filter); err != nil {
+		return nil, err
+	}
+	filter.EditorID = user.ID
+	filter.EditorRoles = []string{models.CollaboratorRoleOwner, models.CollaboratorRoleCoAuthor}
+	if op.Action == BulkPostDelete {
+		filter.EditorRoles = []string{models.CollaboratorRoleOwner}
+	}
+
+	posts, total, err := s.repo.FindAll(filter, 0, s.maxPosts)
+	if err != nil {
+		return nil, err
+	}
+	if total > int64(s.maxPosts) {
+		return nil, tooMany
+	}
+
+	ids := make([]uint, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, post.ID)
+	}
+	return ids, nil
+}
+
+// NormalizeTags trims and lower-cases tag names, dropping duplicates
+func NormalizeTags(names []string) ([]string, error) {
+	seen := make(map[string]bool, len(names))
+	tags := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			return nil, errors.BadRequest("Tags can't be empty", "Client sent an empty tag")
+		}
+		if utf8.RuneCountInString(name) > maxTagLength {
+			return nil, errors.BadRequest(
+				fmt.Sprintf("Tags must be at most %d characters", maxTagLength),
+				"Client sent an oversized tag",
+			)
+		}
+		if !seen[name] {
+			seen[name] = true
+			tags = append(tags, name)
+		}
+	}
+	if len(tags) > maxTagsPerPost {
+		return nil, errors.BadRequest(
+			fmt.Sprintf("A post can have at most %d tags", maxTagsPerPost),
+			"Client sent too many tags",
+		)
+	}
+	return tags, nil
+}
+
+// mergeTags returns the names of the existing tags together with the added ones
+func mergeTags(existing []models.Tag, added []string) []string {
+	names := make([]string, 0, len(existing)+len(added))
+	seen := make(map[string]bool, len(existing)+len(added))
+	for _, tag := range existing {
+		seen[tag.Name] = true
+		names = append(names, tag.Name)
+	}
+	for _, name := range added {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"crud_api/errors"
+	"crud_api/models"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Locales lists the languages posts can be written in. Default is the language of posts that
+// don't say otherwise and the last fallback when negotiating.
+type Locales struct {
+	Supported []string
+	Default   string
+}
+
+// IsSupported reports whether posts can be written in the locale
+func (l Locales) IsSupported(locale string) bool {
+	return slices.Contains(l.Supported, locale)
+}
+
+// Negotiate returns the supported locales the reader prefers, best first and ending with the
+// default locale. An explicit lang wins over the Accept-Language header and must be supported.
+func (l Locales) Negotiate(lang, acceptLanguage string) ([]string, error) {
+	var preferred []string
+	if lang != "" {
+		locale := l.match(lang)
+		if locale == "" {
+			return nil, errors.BadRequest(
+				"lang must be one of "+strings.Join(l.Supported, ", "),
+				fmt.Sprintf("Client asked for unsupported language '%s'", lang),
+			)
+		}
+		preferred = append(preferred, locale)
+	} else {
+		for _, tag := range parseAcceptLanguage(acceptLanguage) {
+			if locale := l.match(tag); locale != "" && !slices.Contains(preferred, locale) {
+				preferred = append(preferred, locale)
+			}
+		}
+	}
+	if !slices.Contains(preferred, l.Default) {
+		preferred = append(preferred, l.Default)
+	}
+	return preferred, nil
+}
+
+// match maps a language tag to a supported locale, trying the tag itself and then its primary
+// language, so en-US matches en
+func (l Locales) match(tag string) string {
+	tag = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+	for _, candidate := range []string{tag, strings.SplitN(tag, "-", 2)[0]} {
+		for _, locale := range l.Supported {
+			if strings.EqualFold(locale, candidate) {
+				return locale
+			}
+		}
+	}
+	return ""
+}
+
+// parseAcceptLanguage returns the language tags of an Accept-Language header by descending
+// quality. Wildcards and tags with q=0 are dropped.
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		tag := strings.TrimSpace(fields[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			name, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.EqualFold(name, "q") {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if q > 0 {
+			tags = append(tags, weighted{tag, q})
+		}
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+	result := make([]string, 0, len(tags))
+	for _, t := range tags {
+		result = append(result, t.tag)
+	}
+	return result
+}
+
+// validatePostLocale fills in the default locale and rejects unsupported ones
+func (l Locales) validatePostLocale(post *models.Post) error {
+	if post.Locale == "" {
+		post.Locale = l.Default
+	}
+	if !l.IsSupported(post.Locale) {
+		return errors.BadRequest(
+			"Locale must be one of "+strings.Join(l.Supported, ", "),
+			fmt.Sprintf("Client sent unsupported post locale '%s'", post.Locale),
+		)
+	}
+	return nil
+}
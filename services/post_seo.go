@@ -0,0 +1,55 @@
+package services
+
+import (
+	"crud_api/errors"
+	"crud_api/models"
+	"fmt"
+	"net/url"
+	"unicode/utf8"
+)
+
+// Twitter card types a post can ask for
+const (
+	TwitterCardSummary      = "summary"
+	TwitterCardSummaryLarge = "summary_large_image"
+)
+
+// seoLimits holds the longest accepted value of each metadata field, in characters
+var seoLimits = []struct {
+	name  string
+	value func(*models.Post) string
+	max   int
+}{
+	{"excerpt", func(p *models.Post) string { return p.Excerpt }, 500},
+	{"meta_title", func(p *models.Post) string { return p.MetaTitle }, 100},
+	{"meta_description", func(p *models.Post) string { return p.MetaDescription }, 300},
+	{"canonical_url", func(p *models.Post) string { return p.CanonicalURL }, 2048},
+	{"og_title", func(p *models.Post) string { return p.OGTitle }, 100},
+	{"og_description", func(p *models.Post) string { return p.OGDescription }, 300},
+}
+
+// validatePostSEO checks the search engine and social preview metadata of a post
+func validatePostSEO(post *models.Post) error {
+	for _, field := range seoLimits {
+		if length := utf8.RuneCountInString(field.value(post)); length > field.max {
+			return errors.BadRequest(
+				fmt.Sprintf("%s must be at most %d characters", field.name, field.max),
+				fmt.Sprintf("Client sent a %s of %d characters", field.name, length),
+			)
+		}
+	}
+
+	if post.CanonicalURL != "" {
+		parsed, err := url.Parse(post.CanonicalURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return errors.BadRequest("canonical_url must be an absolute http or https URL", "Client sent invalid canonical URL '"+post.CanonicalURL+"'")
+		}
+	}
+
+	switch post.TwitterCard {
+	case "", TwitterCardSummary, TwitterCardSummaryLarge:
+	default:
+		return errors.BadRequest("twitter_card must be summary or summary_large_image", "Client sent unknown Twitter card '"+post.TwitterCard+"'")
+	}
+	return nil
+}
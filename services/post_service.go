@@ -1,37 +1,85 @@
 package services
 
 import (
+	"context"
 	"crud_api/errors"
 	"crud_api/models"
 	"crud_api/repositories"
+	"fmt"
+	"strings"
+)
+
+// maxMediaPerPost caps the files attached to a single post
+const maxMediaPerPost = 20
+
+// Related posts listed when the client doesn't ask for a number, and the most it can ask for
+const (
+	defaultRelatedPosts = 5
+	maxRelatedPosts     = repositories.MaxRelatedPosts
 )
 
 type PostService interface {
 	Create(post *models.Post) error
 	GetByID(id uint) (*models.Post, error)
-	GetAll(search, categoryID, authorID string, offset, limit int) ([]models.Post, int64, error)
-	GetByAuthorID(authorID string, offset, limit int) ([]models.Post, int64, error)
+	GetAll(filter repositories.PostFilter, offset, limit int) ([]models.Post, int64, error)
+	GetPage(filter repositories.PostFilter, after, before *repositories.Cursor, limit int, withTotal bool) (*repositories.PostPage, error)
 	Update(post *models.Post, userID uint) error
 	Delete(post *models.Post, userID uint) error
+	// Related returns the published posts with the most in common with the post, best first.
+	// limit defaults to 5 and is capped at 20.
+	Related(id uint, limit int) ([]models.Post, error)
+	// NegotiateLocales returns the languages a listing should prefer, from the lang parameter or
+	// else the Accept-Language header, ending with the default language
+	NegotiateLocales(lang, acceptLanguage string) ([]string, error)
+	// LinkTranslation adds translationID to the translation group of postID
+	LinkTranslation(postID, translationID, userID uint) error
+	// UnlinkTranslation takes the post out of its translation group
+	UnlinkTranslation(postID, userID uint) error
+	// AnnotateTranslations lists the published translations of the posts
+	AnnotateTranslations(posts []models.Post) error
+	// BackfillTextStats computes the excerpt, word count and reading time of posts saved before
+	// they were introduced and returns how many posts it updated
+	BackfillTextStats(ctx context.Context) (int, error)
 }
 
 type postService struct {
-	repo repositories.PostRepository
+	repo    repositories.PostRepository
+	media   repositories.MediaRepository
+	locales Locales
 }
 
-func NewPostService(repo repositories.PostRepository) PostService {
-	return &postService{repo}
+// NewPostService creates a PostService; posts can be written in the supported locales, which always include the default one
+func NewPostService(repo repositories.PostRepository, media repositories.MediaRepository, locales Locales) PostService {
+	if !locales.IsSupported(locales.Default) {
+		locales.Supported = append(locales.Supported, locales.Default)
+	}
+	return &postService{repo, media, locales}
 }
 
 func (s *postService) Create(post *models.Post) error {
+	if post.Status != "" && !models.IsPostStatus(post.Status) {
+		return errors.BadRequest("Status must be one of draft, published or archived", "Client sent unknown post status '"+post.Status+"'")
+	}
+
+	if err := s.prepare(post); err != nil {
+		return err
+	}
+	media, err := s.attachableMedia(post)
+	if err != nil {
+		return err
+	}
+
 	existing, err := s.repo.FindDuplicate(post.Title, post.AuthorID)
 	if err != nil {
 		// Allow only "not found" errors to proceed with creation
 		if appErr, ok := err.(*errors.AppErrors); ok && appErr.Code == 404 {
-			if createdErr := s.repo.Create(post); createdErr != nil {
-				return createdErr
-			}
-			return nil
+			post.Media = nil
+			return s.repo.Transaction(func(tx repositories.PostRepository) error {
+				if err := tx.Create(post); err != nil {
+					return err
+				}
+				return tx.SetMedia(post, media)
+			})
 		}
 		return err
 	}
@@ -53,37 +101,74 @@ func (s *postService) GetByID(id uint) (*models.Post, error) {
 
 }
 
-func (s *postService) GetAll(search, categoryID, authorID string, offset, limit int) ([]models.Post, int64, error) {
-	posts, count, err := s.repo.FindAll(search, categoryID, authorID, offset, limit)
+func (s *postService) GetAll(filter repositories.PostFilter, offset, limit int) ([]models.Post, int64, error) {
+	if err := validatePostFilter(filter); err != nil {
+		return nil, 0, err
+	}
+
+	posts, count, err := s.repo.FindAll(filter, offset, limit)
 	if err != nil {
 		return nil, 0, err
 	}
 	return posts, count, nil
 }
 
-func (s *postService) GetByAuthorID(authorID string, offset, limit int) ([]models.Post, int64, error) {
-	posts, count, err := s.repo.FindAll("", "", authorID, offset, limit)
+func (s *postService) GetPage(filter repositories.PostFilter, after, before *repositories.Cursor, limit int, withTotal bool) (*repositories.PostPage, error) {
+	if err := validatePostFilter(filter); err != nil {
+		return nil, err
+	}
+	if after != nil && before != nil {
+		return nil, errors.BadRequest("Use either after or before, not both", "Client sent both pagination cursors")
+	}
+
+	page, err := s.repo.FindPage(filter, after, before, limit)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
-	return posts, count, nil
+	if withTotal {
+		total, err := s.repo.Count(filter)
+		if err != nil {
+			return nil, err
+		}
+		page.Total = &total
+	}
+	return page, nil
+}
+
+func (s *postService) Related(id uint, limit int) ([]models.Post, error) {
+	if limit <= 0 {
+		limit = defaultRelatedPosts
+	}
+	if limit > maxRelatedPosts {
+		limit = maxRelatedPosts
+	}
+	return s.repo.FindRelated(id, limit)
 }
 
 func (s *postService) Update(post *models.Post, userID uint) error {
-	if post.AuthorID != userID {
-		return errors.Forbidden("You are not authorized to edit this post", "Tried to edit unauthorized post")
+	if err := authorizePostChange(post, userID, "edit"); err != nil {
+		return err
 	}
 
-	err := s.repo.Update(post)
+	if err := s.prepare(post); err != nil {
+		return err
+	}
+	media, err := s.attachableMedia(post)
 	if err != nil {
 		return err
 	}
-	return nil
+
+	return s.repo.Transaction(func(tx repositories.PostRepository) error {
+		if err := tx.Update(post); err != nil {
+			return err
+		}
+		return tx.SetMedia(post, media)
+	})
 }
 
 func (s *postService) Delete(post *models.Post, userID uint) error {
-	if post.AuthorID != userID {
-		return errors.Forbidden("You are not authorized to delete this post", "Tried to delete unauthorized post")
+	if err := authorizePostChange(post, userID, "delete"); err != nil {
+		return err
 	}
 	err := s.repo.Delete(post)
 	if err != nil {
@@ -91,3 +176,285 @@ func (s *postService) Delete(post *models.Post, userID uint) error {
 	}
 	return nil
 }
+
+func (s *postService) NegotiateLocales(lang, acceptLanguage string) ([]string, error) {
+	return s.locales.Negotiate(lang, acceptLanguage)
+}
+
+func (s *postService) LinkTranslation(postID, translationID, userID uint) error {
+	if postID == translationID {
+		return errors.BadRequest("A post can't be a translation of itself", "Tried to link post to itself")
+	}
+
+	post, err := s.repo.FindByID(postID)
+	if err != nil {
+		return err
+	}
+	translation, err := s.repo.FindByID(translationID)
+	if err != nil {
+		return err
+	}
+	for _, p := range []*models.Post{post, translation} {
+		if err := authorizePostChange(p, userID, "edit"); err != nil {
+			return err
+		}
+	}
+
+	if translation.TranslationGroupID != nil {
+		if post.TranslationGroupID != nil && *post.TranslationGroupID == *translation.TranslationGroupID {
+			return nil
+		}
+		return errors.Conflict(
+			"The post is already linked to other translations; unlink it first",
+			fmt.Sprintf("Post '%d' belongs to translation group '%d'", translation.ID, *translation.TranslationGroupID),
+		)
+	}
+
+	// A new group is named after the post it starts from
+	groupID := post.ID
+	members := []models.Post{*post}
+	if post.TranslationGroupID != nil {
+		groupID = *post.TranslationGroupID
+		if members, err = s.repo.FindTranslations([]uint{groupID}, false); err != nil {
+			return err
+		}
+	}
+	if err := checkTranslationLocale(members, translation.ID, translation.Locale); err != nil {
+		return err
+	}
+
+	postIDs := []uint{translation.ID}
+	if post.TranslationGroupID == nil {
+		postIDs = append(postIDs, post.ID)
+	}
+	return s.repo.SetTranslationGroup(postIDs, &groupID)
+}
+
+func (s *postService) UnlinkTranslation(postID, userID uint) error {
+	post, err := s.repo.FindByID(postID)
+	if err != nil {
+		return err
+	}
+	if err := authorizePostChange(post, userID, "edit"); err != nil {
+		return err
+	}
+	if post.TranslationGroupID == nil {
+		return errors.NotFound("The post is not linked to any translations", fmt.Sprintf("Post '%d' has no translation group", post.ID))
+	}
+
+	// A group left with a single post is dissolved
+	postIDs := []uint{post.ID}
+	remaining, err := s.repo.FindTranslations([]uint{*post.TranslationGroupID}, false)
+	if err != nil {
+		return err
+	}
+	if len(remaining) == 2 {
+		postIDs = []uint{remaining[0].ID, remaining[1].ID}
+	}
+	return s.repo.SetTranslationGroup(postIDs, nil)
+}
+
+func (s *postService) AnnotateTranslations(posts []models.Post) error {
+	var groupIDs []uint
+	seen := make(map[uint]bool)
+	for _, post := range posts {
+		if post.TranslationGroupID != nil && !seen[*post.TranslationGroupID] {
+			seen[*post.TranslationGroupID] = true
+			groupIDs = append(groupIDs, *post.TranslationGroupID)
+		}
+	}
+	if len(groupIDs) == 0 {
+		return nil
+	}
+
+	translations, err := s.repo.FindTranslations(groupIDs, true)
+	if err != nil {
+		return err
+	}
+	for i := range posts {
+		if posts[i].TranslationGroupID == nil {
+			continue
+		}
+		for _, translation := range translations {
+			if *translation.TranslationGroupID == *posts[i].TranslationGroupID && translation.ID != posts[i].ID {
+				posts[i].Translations = append(posts[i].Translations, models.PostTranslation{
+					ID:     translation.ID,
+					Locale: translation.Locale,
+					Title:  translation.Title,
+				})
+			}
+		}
+	}
+	return nil
+}
+
+func (s *postService) BackfillTextStats(ctx context.Context) (int, error) {
+	const batchSize = 100
+	updated := 0
+	var afterID uint
+	for ctx.Err() == nil {
+		posts, err := s.repo.FindWithoutTextStats(afterID, batchSize)
+		if err != nil || len(posts) == 0 {
+			return updated, err
+		}
+		afterID = posts[len(posts)-1].ID
+
+		for i := range posts {
+			fillTextStats(&posts[i])
+		}
+		if err := s.repo.UpdateTextStats(posts); err != nil {
+			return updated, err
+		}
+		updated += len(posts)
+	}
+	return updated, nil
+}
+
+// prepare validates the metadata of a post about to be saved and fills in the generated fields
+func (s *postService) prepare(post *models.Post) error {
+	if err := validatePostSEO(post); err != nil {
+		return err
+	}
+	if err := s.locales.validatePostLocale(post); err != nil {
+		return err
+	}
+	if post.TranslationGroupID != nil {
+		translations, err := s.repo.FindTranslations([]uint{*post.TranslationGroupID}, false)
+		if err != nil {
+			return err
+		}
+		if err := checkTranslationLocale(translations, post.ID, post.Locale); err != nil {
+			return err
+		}
+	}
+
+	post.FeaturedMedia = nil
+	if post.FeaturedMediaID != nil {
+		media, err := s.media.FindByID(*post.FeaturedMediaID)
+		if err != nil {
+			if appErr, ok := err.(*errors.AppErrors); ok && appErr.Code == 404 {
+				return errors.BadRequest("The featured image does not exist", fmt.Sprintf("Client featured unknown media %d", *post.FeaturedMediaID))
+			}
+			return err
+		}
+		if !isPostWriter(post, media.OwnerID) {
+			return errors.Forbidden("You can only feature uploads of the post's authors", fmt.Sprintf("Tried to feature media %d of user %d", media.ID, media.OwnerID))
+		}
+		if !strings.HasPrefix(media.ContentType, "image/") {
+			return errors.BadRequest("The featured image must be an image", fmt.Sprintf("Client featured media %d of type %s", media.ID, media.ContentType))
+		}
+	}
+
+	fillTextStats(post)
+	return nil
+}
+
+// attachableMedia loads the media the post refers to by ID, which must all be uploads of the post's
+// owners or co-authors
+func (s *postService) attachableMedia(post *models.Post) ([]models.Media, error) {
+	ids := make([]uint, 0, len(post.Media))
+	seen := make(map[uint]bool, len(post.Media))
+	for _, media := range post.Media {
+		if !seen[media.ID] {
+			seen[media.ID] = true
+			ids = append(ids, media.ID)
+		}
+	}
+	if len(ids) > maxMediaPerPost {
+		return nil, errors.BadRequest(
+			fmt.Sprintf("A post can have at most %d media", maxMediaPerPost),
+			fmt.Sprintf("Client attached %d media to a post", len(ids)),
+		)
+	}
+
+	media, err := s.media.FindByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(media) != len(ids) {
+		return nil, errors.BadRequest("Some of the media do not exist", "Client attached unknown media to a post")
+	}
+	for _, m := range media {
+		if !isPostWriter(post, m.OwnerID) {
+			return nil, errors.Forbidden("You can only attach uploads of the post's authors", fmt.Sprintf("Tried to attach media %d of user %d", m.ID, m.OwnerID))
+		}
+	}
+	return media, nil
+}
+
+// authorizePostChange holds the rule for who may edit or delete a post, shared by single and bulk
+// changes: owners may do anything, co-authors anything but deleting the post
+func authorizePostChange(post *models.Post, userID uint, action string) error {
+	role := post.RoleOf(userID)
+	if role == models.CollaboratorRoleOwner || (role == models.CollaboratorRoleCoAuthor && action != "delete") {
+		return nil
+	}
+	if role == models.CollaboratorRoleCoAuthor {
+		return errors.Forbidden("Only owners can "+action+" this post", "Co-author tried to "+action+" post")
+	}
+	return errors.Forbidden("You are not authorized to "+action+" this post", "Tried to "+action+" unauthorized post")
+}
+
+// isPostWriter reports whether the user may edit the post, i.e. is one of its owners or co-authors
+func isPostWriter(post *models.Post, userID uint) bool {
+	role := post.RoleOf(userID)
+	return role == models.CollaboratorRoleOwner || role == models.CollaboratorRoleCoAuthor
+}
+
+// CanSeePost reports whether the viewer may see the post: published posts are public, drafts and
+// archived posts are only visible to their author, collaborators and staff. A zero viewer is a
+// signed-out visitor.
+func CanSeePost(post *models.Post, viewer models.User) bool {
+	if post.Status == models.PostStatusPublished {
+		return true
+	}
+	return viewer.ID != 0 && (viewer.IsStaff() || post.RoleOf(viewer.ID) != "")
+}
+
+// findVisiblePost loads a live post the viewer may see; posts they may not see are reported as
+// not found, like in the post details, so their existence isn't revealed
+func findVisiblePost(repo repositories.PostRepository, id uint, viewer models.User) (*models.Post, error) {
+	post, err := repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !CanSeePost(post, viewer) {
+		return nil, errors.NotFound("Post not found", fmt.Sprintf("Post '%d' is not published", post.ID))
+	}
+	return post, nil
+}
+
+// checkTranslationLocale makes sure a translation group has at most one post per language;
+// postID is the post taking the locale, which may already be in the group
+func checkTranslationLocale(translations []models.Post, postID uint, locale string) error {
+	for _, translation := range translations {
+		if translation.ID != postID && translation.Locale == locale {
+			return errors.Conflict(
+				fmt.Sprintf("The post already has a translation in '%s'", locale),
+				fmt.Sprintf("Post '%d' is the '%s' translation of the group", translation.ID, locale),
+			)
+		}
+	}
+	return nil
+}
+
+// validatePostFilter rejects filter combinations the listing can't answer
+func validatePostFilter(filter repositories.PostFilter) error {
+	for _, status := range filter.Statuses {
+		if !models.IsPostStatus(status) {
+			return errors.BadRequest("Status must be one of draft, published or archived", "Client filtered by unknown post status '"+status+"'")
+		}
+	}
+	for _, field := range filter.Sort {
+		if field.Field == "relevance" && filter.Search == "" {
+			return errors.BadRequest("Sorting by relevance requires a search term", "Client asked for relevance order without search")
+		}
+	}
+	if filter.CreatedAfter != nil && filter.CreatedBefore != nil && !filter.CreatedAfter.Before(*filter.CreatedBefore) {
+		return errors.BadRequest("created_after must be earlier than created_before", "Client sent an empty creation date range")
+	}
+	if filter.UpdatedAfter != nil && filter.UpdatedBefore != nil && !filter.UpdatedAfter.Before(*filter.UpdatedBefore) {
+		return errors.BadRequest("updated_after must be earlier than updated_before", "Client sent an empty update date range")
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"crud_api/errors"
+	"crud_api/models"
+	"crud_api/repositories"
+	"time"
+)
+
+// maxStatsRange limits how many days a single stats request may cover
+const maxStatsRange = 366
+
+type PostStats struct {
+	PostID     uint
+	TotalViews int64 // all time
+	From, To   time.Time
+	Series     []models.PostDailyViews // one entry per day in [From, To], zero-filled
+}
+
+type PostStatsService interface {
+	GetStats(postID, userID uint, from, to time.Time) (*PostStats, error)
+}
+
+type postStatsService struct {
+	repo     repositories.ViewRepository
+	postRepo repositories.PostRepository
+}
+
+func NewPostStatsService(repo repositories.ViewRepository, postRepo repositories.PostRepository) PostStatsService {
+	return &postStatsService{repo: repo, postRepo: postRepo}
+}
+
+func (s *postStatsService) GetStats(postID, userID uint, from, to time.Time) (*PostStats, error) {
+	post, err := s.postRepo.FindByID(postID)
+	if err != nil {
+		return nil, err
+	}
+	if !isPostWriter(post, userID) {
+		return nil, errors.Forbidden("You are not authorized to view statistics of this post", "Tried to read stats of unauthorized post")
+	}
+
+	from = from.UTC().Truncate(24 * time.Hour)
+	to = to.UTC().Truncate(24 * time.Hour)
+	if to.Before(from) {
+		return nil, errors.BadRequest("'from' must not be after 'to'", "Client sent inverted stats range")
+	}
+	if to.Sub(from) > maxStatsRange*24*time.Hour {
+		return nil, errors.BadRequest("Statistics can cover at most 366 days", "Client sent oversized stats range")
+	}
+
+	days, err := s.repo.DailyViews(postID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.repo.TotalViews(postID)
+	if err != nil {
+		return nil, err
+	}
+
+	byDay := make(map[string]int64, len(days))
+	for _, day := range days {
+		byDay[day.Day.Format("2006-01-02")] = day.Views
+	}
+
+	var series []models.PostDailyViews
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		series = append(series, models.PostDailyViews{PostID: postID, Day: day, Views: byDay[day.Format("2006-01-02")]})
+	}
+
+	return &PostStats{PostID: postID, TotalViews: total, From: from, To: to, Series: series}, nil
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"crud_api/models"
+	"html"
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Reading speeds used to estimate reading time. Chinese and Japanese are read character by
+// character, so their characters are counted as words and read faster.
+const (
+	wordsPerMinute = 200
+	cjkPerMinute   = 500
+)
+
+// excerptLength is the length of generated excerpts, in characters
+const excerptLength = 200
+
+// TextStats describes the readable text of a post
+type TextStats struct {
+	Words       int // CJK characters count as one word each
+	ReadingTime int // minutes, rounded up; 0 for posts without text
+}
+
+var (
+	htmlCommentPattern     = regexp.MustCompile(`(?s)<!--.*?-->`)
+	htmlSkipPattern        = regexp.MustCompile(`(?is)<(script|style)\b.*?</(script|style)>`)
+	htmlBlockPattern       = regexp.MustCompile(`(?i)</?(p|div|br|li|ul|ol|h[1-6]|blockquote|pre|tr|td|th|table|section|article|hr)\b[^>]*>`)
+	htmlTagPattern         = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+	mdFencePattern         = regexp.MustCompile("(?m)^\\s*(```|~~~).*$")
+	mdImagePattern         = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	mdLinkPattern          = regexp.MustCompile(`\[([^\]]*)\](\([^)]*\)|\[[^\]]*\])`)
+	mdReferencePattern     = regexp.MustCompile(`(?m)^\s*\[[^\]]+\]:\s+\S+.*$`)
+	mdAutolinkPattern      = regexp.MustCompile(`<((https?|mailto):[^>\s]+)>`)
+	mdHeadingPattern       = regexp.MustCompile(`(?m)^\s{0,3}#{1,6}\s+|\s+#+\s*$`)
+	mdBlockPrefixPattern   = regexp.MustCompile(`(?m)^\s*(>\s*)+|^\s*([-*+]|\d+[.)])\s+`)
+	mdRulePattern          = regexp.MustCompile(`(?m)^\s*([-*_]\s*){3,}$`)
+	mdEmphasisPattern      = regexp.MustCompile("(\\*{1,3}|_{1,3}|~~|`+)")
+	mdTableBorderPattern   = regexp.MustCompile(`(?m)^\s*\|?(\s*:?-+:?\s*\|)+\s*:?-*:?\s*$`)
+	mdTablePipePattern     = regexp.MustCompile(`[ \t]*\|[ \t]*`)
+	blankLinesPattern      = regexp.MustCompile(`\n\s*\n+`)
+	horizontalSpacePattern = regexp.MustCompile(`[ \t\f\r]+`)
+)
+
+// PlainText removes HTML and Markdown markup, keeping the text a reader would see: link
+// texts, image descriptions and code. Paragraphs stay separated by blank lines.
+func PlainText(markup string) string {
+	text := strings.ReplaceAll(markup, "\r\n", "\n")
+
+	// HTML first, so tags don't get mistaken for Markdown
+	text = htmlCommentPattern.ReplaceAllString(text, "")
+	text = htmlSkipPattern.ReplaceAllString(text, "")
+	text = htmlBlockPattern.ReplaceAllString(text, "\n")
+	text = htmlTagPattern.ReplaceAllString(text, "")
+
+	text = mdFencePattern.ReplaceAllString(text, "")
+	text = mdImagePattern.ReplaceAllString(text, "$1")
+	text = mdLinkPattern.ReplaceAllString(text, "$1")
+	text = mdReferencePattern.ReplaceAllString(text, "")
+	text = mdAutolinkPattern.ReplaceAllString(text, "$1")
+	text = mdRulePattern.ReplaceAllString(text, "")
+	text = mdHeadingPattern.ReplaceAllString(text, "")
+	text = mdBlockPrefixPattern.ReplaceAllString(text, "")
+	text = mdTableBorderPattern.ReplaceAllString(text, "")
+	text = mdTablePipePattern.ReplaceAllString(text, " ")
+	text = mdEmphasisPattern.ReplaceAllString(text, "")
+
+	// Entities are decoded last so escaped markup stays text
+	text = html.UnescapeString(text)
+	text = horizontalSpacePattern.ReplaceAllString(text, " ")
+	text = blankLinesPattern.ReplaceAllString(text, "\n\n")
+	return strings.TrimSpace(text)
+}
+
+// AnalyzeText counts the words of plain text and estimates how long it takes to read
+func AnalyzeText(text string) TextStats {
+	words, cjk := 0, 0
+	inWord := false
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			cjk++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r):
+			// Marks belong to the word they modify, e.g. Devanagari vowel signs
+			if !inWord {
+				words++
+				inWord = true
+			}
+		case r == '\'' || r == '’' || r == '-':
+			// Keeps "don't" and "well-known" as one word
+		default:
+			inWord = false
+		}
+	}
+
+	stats := TextStats{Words: words + cjk}
+	if stats.Words > 0 {
+		minutes := float64(words)/wordsPerMinute + float64(cjk)/cjkPerMinute
+		stats.ReadingTime = int(math.Ceil(minutes))
+	}
+	return stats
+}
+
+// isCJK reports whether r is written without spaces between words: Chinese characters and
+// Japanese kana. Korean separates words with spaces, so Hangul is counted like other scripts.
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana)
+}
+
+// fillTextStats computes the fields derived from the description: excerpt, word count and reading time
+func fillTextStats(post *models.Post) {
+	text := PlainText(post.Description)
+	stats := AnalyzeText(text)
+	post.AutoExcerpt = generateExcerpt(text)
+	post.WordCount = stats.Words
+	post.ReadingTime = stats.ReadingTime
+}
+
+// generateExcerpt shortens plain text to about excerptLength characters, cutting at a word boundary
+func generateExcerpt(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= excerptLength {
+		return text
+	}
+
+	runes := []rune(text)[:excerptLength]
+	cut := len(runes)
+	for i := len(runes) - 1; i > excerptLength/2; i-- {
+		if unicode.IsSpace(runes[i]) {
+			cut = i
+			break
+		}
+	}
+	return strings.TrimRightFunc(string(runes[:cut]), unicode.IsPunct) + "…"
+}
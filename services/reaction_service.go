@@ -0,0 +1,94 @@
+package services
+
+import (
+	"crud_api/errors"
+	"crud_api/models"
+	"crud_api/repositories"
+	"strings"
+)
+
+type ReactionService interface {
+	React(postID uint, user models.User, reactionType string) error
+	Unreact(postID, userID uint, reactionType string) error
+	Summary(postID, viewerID uint) (map[string]int64, []string, error)
+	Annotate(posts []models.Post, viewerID uint) error
+}
+
+type reactionService struct {
+	repo     repositories.ReactionRepository
+	postRepo repositories.PostRepository
+}
+
+func NewReactionService(repo repositories.ReactionRepository, postRepo repositories.PostRepository) ReactionService {
+	return &reactionService{repo: repo, postRepo: postRepo}
+}
+
+func (s *reactionService) React(postID uint, user models.User, reactionType string) error {
+	if err := validateReactionType(reactionType); err != nil {
+		return err
+	}
+	if _, err := findVisiblePost(s.postRepo, postID, user); err != nil {
+		return err
+	}
+	return s.repo.Add(&models.Reaction{PostID: postID, UserID: user.ID, Type: reactionType})
+}
+
+func (s *reactionService) Unreact(postID, userID uint, reactionType string) error {
+	if err := validateReactionType(reactionType); err != nil {
+		return err
+	}
+	if _, err := s.postRepo.FindByID(postID); err != nil {
+		return err
+	}
+	return s.repo.Remove(postID, userID, reactionType)
+}
+
+func (s *reactionService) Summary(postID, viewerID uint) (map[string]int64, []string, error) {
+	posts := make([]models.Post, 1)
+	posts[0].ID = postID
+	if err := s.Annotate(posts, viewerID); err != nil {
+		return nil, nil, err
+	}
+	return posts[0].ReactionCounts, posts[0].ViewerReactions, nil
+}
+
+// Annotate fills in reaction counts for the posts and, for a signed-in viewer (viewerID != 0),
+// the reactions that viewer left
+func (s *reactionService) Annotate(posts []models.Post, viewerID uint) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	postIDs := make([]uint, 0, len(posts))
+	for _, post := range posts {
+		postIDs = append(postIDs, post.ID)
+	}
+
+	counts, err := s.repo.CountsByPosts(postIDs)
+	if err != nil {
+		return err
+	}
+
+	var mine map[uint][]string
+	if viewerID != 0 {
+		if mine, err = s.repo.TypesByUser(postIDs, viewerID); err != nil {
+			return err
+		}
+	}
+
+	for i := range posts {
+		posts[i].ReactionCounts = counts[posts[i].ID]
+		posts[i].ViewerReactions = mine[posts[i].ID]
+	}
+	return nil
+}
+
+func validateReactionType(reactionType string) error {
+	if !models.IsReactionType(reactionType) {
+		return errors.BadRequest(
+			"Reaction type must be one of "+strings.Join(models.ReactionTypes, ", "),
+			"Client sent unknown reaction type '"+reactionType+"'",
+		)
+	}
+	return nil
+}
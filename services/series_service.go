@@ -0,0 +1,302 @@
+package services
+
+import (
+	"crud_api/errors"
+	"crud_api/models"
+	"crud_api/repositories"
+	"fmt"
+)
+
+// maxPostsPerSeries caps the parts of a single series
+const maxPostsPerSeries = 100
+
+type SeriesService interface {
+	Create(series *models.Series) error
+	GetByID(id uint) (*models.Series, error)
+	List(ownerID uint, limit, offset int) ([]models.Series, int64, error)
+	// Posts lists the parts of the series in reading order; drafts and archived parts only
+	// when includeUnpublished is set
+	Posts(series *models.Series, includeUnpublished bool) ([]models.Post, error)
+	Update(series *models.Series, userID uint) error
+	Delete(series *models.Series, userID uint) error
+	// AddPost appends the post to the series as its last part
+	AddPost(seriesID, postID, userID uint) error
+	RemovePost(seriesID, postID, userID uint) error
+	// Reorder sets the reading order; postIDs must list every part of the series exactly once
+	Reorder(seriesID uint, postIDs []uint, userID uint) error
+	// Annotate fills in the series navigation of the posts that are part of a series
+	Annotate(posts []models.Post) error
+}
+
+type seriesService struct {
+	repo     repositories.SeriesRepository
+	postRepo repositories.PostRepository
+}
+
+func NewSeriesService(repo repositories.SeriesRepository, postRepo repositories.PostRepository) SeriesService {
+	return &seriesService{repo: repo, postRepo: postRepo}
+}
+
+func (s *seriesService) Create(series *models.Series) error {
+	return s.repo.Create(series)
+}
+
+func (s *seriesService) GetByID(id uint) (*models.Series, error) {
+	return s.repo.FindByID(id)
+}
+
+func (s *seriesService) List(ownerID uint, limit, offset int) ([]models.Series, int64, error) {
+	return s.repo.ListByOwner(ownerID, limit, offset)
+}
+
+func (s *seriesService) Posts(series *models.Series, includeUnpublished bool) ([]models.Post, error) {
+	return s.postRepo.FindBySeries([]uint{series.ID}, !includeUnpublished)
+}
+
+func (s *seriesService) Update(series *models.Series, userID uint) error {
+	if err := authorizeSeriesChange(series, userID, "edit"); err != nil {
+		return err
+	}
+	return s.repo.Update(series)
+}
+
+func (s *seriesService) Delete(series *models.Series, userID uint) error {
+	if err := authorizeSeriesChange(series, userID, "delete"); err != nil {
+		return err
+	}
+
+	// The posts stay, they just no longer belong to a series
+	return s.postRepo.Transaction(func(tx repositories.PostRepository) error {
+		if err := tx.LockSeries(series.ID); err != nil {
+			return err
+		}
+		if err := tx.SetSeriesPosts(series.ID, nil); err != nil {
+			return err
+		}
+		return tx.DeleteSeries(series.ID)
+	})
+}
+
+func (s *seriesService) AddPost(seriesID, postID, userID uint) error {
+	series, err := s.repo.FindByID(seriesID)
+	if err != nil {
+		return err
+	}
+	if err := authorizeSeriesChange(series, userID, "add posts to"); err != nil {
+		return err
+	}
+
+	return s.postRepo.Transaction(func(tx repositories.PostRepository) error {
+		if err := tx.LockSeries(series.ID); err != nil {
+			return err
+		}
+
+		post, err := tx.FindByID(postID)
+		if err != nil {
+			return err
+		}
+		if post.AuthorID != series.OwnerID {
+			return errors.Forbidden("Only your own posts can be added to your series", "Tried to add another author's post to a series")
+		}
+		if post.SeriesID != nil {
+			if *post.SeriesID == series.ID {
+				return nil
+			}
+			return errors.Conflict(
+				"The post is already part of another series",
+				fmt.Sprintf("Post '%d' belongs to series '%d'", post.ID, *post.SeriesID),
+			)
+		}
+
+		postIDs, err := memberIDs(tx, series.ID)
+		if err != nil {
+			return err
+		}
+		if len(postIDs) >= maxPostsPerSeries {
+			return errors.BadRequest(
+				fmt.Sprintf("A series can have at most %d posts", maxPostsPerSeries),
+				fmt.Sprintf("Series '%d' is full", series.ID),
+			)
+		}
+		return tx.SetSeriesPosts(series.ID, append(postIDs, post.ID))
+	})
+}
+
+func (s *seriesService) RemovePost(seriesID, postID, userID uint) error {
+	series, err := s.repo.FindByID(seriesID)
+	if err != nil {
+		return err
+	}
+	if err := authorizeSeriesChange(series, userID, "remove posts from"); err != nil {
+		return err
+	}
+
+	return s.postRepo.Transaction(func(tx repositories.PostRepository) error {
+		if err := tx.LockSeries(series.ID); err != nil {
+			return err
+		}
+
+		postIDs, err := memberIDs(tx, series.ID)
+		if err != nil {
+			return err
+		}
+		remaining := make([]uint, 0, len(postIDs))
+		for _, id := range postIDs {
+			if id != postID {
+				remaining = append(remaining, id)
+			}
+		}
+		if len(remaining) == len(postIDs) {
+			return errors.NotFound(
+				"Post is not part of this series",
+				fmt.Sprintf("Post '%d' not found in series '%d'", postID, series.ID),
+			)
+		}
+		return tx.SetSeriesPosts(series.ID, remaining)
+	})
+}
+
+func (s *seriesService) Reorder(seriesID uint, postIDs []uint, userID uint) error {
+	series, err := s.repo.FindByID(seriesID)
+	if err != nil {
+		return err
+	}
+	if err := authorizeSeriesChange(series, userID, "reorder"); err != nil {
+		return err
+	}
+
+	return s.postRepo.Transaction(func(tx repositories.PostRepository) error {
+		if err := tx.LockSeries(series.ID); err != nil {
+			return err
+		}
+
+		current, err := memberIDs(tx, series.ID)
+		if err != nil {
+			return err
+		}
+		if !samePosts(current, postIDs) {
+			return errors.BadRequest(
+				"post_ids must list every post of the series exactly once",
+				fmt.Sprintf("Reorder of series '%d' doesn't match its posts", series.ID),
+			)
+		}
+		return tx.SetSeriesPosts(series.ID, postIDs)
+	})
+}
+
+// Annotate places every post that is part of a series among the published parts of that
+// series. Unpublished posts are only shown to their author, so they are counted as a part
+// of their series when they are the post being annotated.
+func (s *seriesService) Annotate(posts []models.Post) error {
+	var seriesIDs []uint
+	seen := make(map[uint]bool)
+	for _, post := range posts {
+		if post.SeriesID != nil && !seen[*post.SeriesID] {
+			seen[*post.SeriesID] = true
+			seriesIDs = append(seriesIDs, *post.SeriesID)
+		}
+	}
+	if len(seriesIDs) == 0 {
+		return nil
+	}
+
+	series, err := s.repo.FindByIDs(seriesIDs)
+	if err != nil {
+		return err
+	}
+	titles := make(map[uint]string, len(series))
+	for _, item := range series {
+		titles[item.ID] = item.Title
+	}
+
+	members, err := s.postRepo.FindBySeries(seriesIDs, true)
+	if err != nil {
+		return err
+	}
+	parts := make(map[uint][]models.Post)
+	for _, member := range members {
+		parts[*member.SeriesID] = append(parts[*member.SeriesID], member)
+	}
+
+	for i := range posts {
+		post := &posts[i]
+		if post.SeriesID == nil {
+			continue
+		}
+		title, ok := titles[*post.SeriesID]
+		if !ok {
+			continue
+		}
+		post.SeriesNav = seriesNav(*post.SeriesID, title, parts[*post.SeriesID], *post)
+	}
+	return nil
+}
+
+// memberIDs returns the IDs of all parts of the series in reading order
+func memberIDs(repo repositories.PostRepository, seriesID uint) ([]uint, error) {
+	posts, err := repo.FindBySeries([]uint{seriesID}, false)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, post.ID)
+	}
+	return ids, nil
+}
+
+// authorizeSeriesChange holds the rule for who may change a series and its parts: only its owner
+func authorizeSeriesChange(series *models.Series, userID uint, action string) error {
+	if series.OwnerID != userID {
+		return errors.Forbidden("You are not authorized to "+action+" this series", "Tried to "+action+" unauthorized series")
+	}
+	return nil
+}
+
+// seriesNav locates post among the published parts, inserting it by position when it isn't published
+func seriesNav(seriesID uint, title string, parts []models.Post, post models.Post) *models.SeriesNav {
+	index := -1
+	for i, part := range parts {
+		if part.ID == post.ID {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		index = len(parts)
+		for i, part := range parts {
+			if part.SeriesPosition > post.SeriesPosition {
+				index = i
+				break
+			}
+		}
+		parts = append(parts[:index:index], append([]models.Post{post}, parts[index:]...)...)
+	}
+
+	nav := &models.SeriesNav{ID: seriesID, Title: title, Position: index + 1, Total: len(parts)}
+	if index > 0 {
+		nav.Previous = &models.SeriesPart{ID: parts[index-1].ID, Title: parts[index-1].Title}
+	}
+	if index < len(parts)-1 {
+		nav.Next = &models.SeriesPart{ID: parts[index+1].ID, Title: parts[index+1].Title}
+	}
+	return nav
+}
+
+// samePosts reports whether ids lists exactly the posts in current, in any order
+func samePosts(current, ids []uint) bool {
+	if len(current) != len(ids) {
+		return false
+	}
+	remaining := make(map[uint]bool, len(current))
+	for _, id := range current {
+		remaining[id] = true
+	}
+	for _, id := range ids {
+		if !remaining[id] {
+			return false
+		}
+		delete(remaining, id)
+	}
+	return true
+}
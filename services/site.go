@@ -0,0 +1,23 @@
+package services
+
+import "fmt"
+
+// Site describes the public website the blog is read on. The API doesn't serve those pages
+// itself, but feeds and sitemaps link to them.
+type Site struct {
+	Title       string
+	Description string
+	URL         string // base URL without trailing slash, e.g. https://blog.example.com
+}
+
+func (s Site) PostURL(id uint) string {
+	return fmt.Sprintf("%s/posts/%d", s.URL, id)
+}
+
+func (s Site) CategoryURL(id uint) string {
+	return fmt.Sprintf("%s/categories/%d", s.URL, id)
+}
+
+func (s Site) AuthorURL(id uint) string {
+	return fmt.Sprintf("%s/authors/%d", s.URL, id)
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"crud_api/errors"
+	"crud_api/repositories"
+	"crud_api/sitemap"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type SitemapService interface {
+	// Pages returns the number of sitemaps needed to list every page; above one an index is served
+	Pages() (int, error)
+	// Page returns the URLs of sitemap n, counting from 1
+	Page(n int) ([]sitemap.URL, error)
+	// Index lists the sitemaps with the latest modification of each
+	Index() ([]sitemap.URL, error)
+}
+
+type sitemapService struct {
+	repo     repositories.SitemapRepository
+	site     Site
+	pageSize int
+	ttl      time.Duration
+
+	mu      sync.Mutex
+	urls    []sitemap.URL
+	builtAt time.Time
+}
+
+// NewSitemapService creates a SitemapService that rebuilds the URL list from the database at most once
+// per ttl. Sitemaps hold up to pageSize URLs, capped at the protocol limit.
+func NewSitemapService(repo repositories.SitemapRepository, site Site, pageSize int, ttl time.Duration) SitemapService {
+	if pageSize <= 0 || pageSize > sitemap.MaxURLs {
+		pageSize = sitemap.MaxURLs
+	}
+	return &sitemapService{repo: repo, site: site, pageSize: pageSize, ttl: ttl}
+}
+
+func (s *sitemapService) Pages() (int, error) {
+	urls, err := s.load()
+	if err != nil {
+		return 0, err
+	}
+	return max(1, (len(urls)+s.pageSize-1)/s.pageSize), nil
+}
+
+func (s *sitemapService) Page(n int) ([]sitemap.URL, error) {
+	urls, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	start := (n - 1) * s.pageSize
+	if n < 1 || (start >= len(urls) && n != 1) {
+		return nil, errors.NotFound("Sitemap not found", fmt.Sprintf("Sitemap page %d does not exist", n))
+	}
+	return urls[start:min(start+s.pageSize, len(urls))], nil
+}
+
+func (s *sitemapService) Index() ([]sitemap.URL, error) {
+	pages, err := s.Pages()
+	if err != nil {
+		return nil, err
+	}
+
+	index := make([]sitemap.URL, 0, pages)
+	for n := 1; n <= pages; n++ {
+		urls, err := s.Page(n)
+		if err != nil {
+			return nil, err
+		}
+		index = append(index, sitemap.URL{Loc: fmt.Sprintf("%s/sitemaps/%d.xml", s.site.URL, n), LastMod: sitemap.LastMod(urls)})
+	}
+	return index, nil
+}
+
+// load returns the cached URL list, rebuilding it when it is older than the ttl. Concurrent
+// callers wait for a single rebuild.
+func (s *sitemapService) load() ([]sitemap.URL, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.urls != nil && time.Since(s.builtAt) < s.ttl {
+		return s.urls, nil
+	}
+
+	urls, err := s.build()
+	if err != nil {
+		return nil, err
+	}
+	s.urls, s.builtAt = urls, time.Now()
+	return urls, nil
+}
+
+// build lists the home page, then posts, categories and authors
+func (s *sitemapService) build() ([]sitemap.URL, error) {
+	posts, err := s.repo.Posts()
+	if err != nil {
+		return nil, err
+	}
+	categories, err := s.repo.Categories()
+	if err != nil {
+		return nil, err
+	}
+	authors, err := s.repo.Authors()
+	if err != nil {
+		return nil, err
+	}
+
+	urls := make([]sitemap.URL, 0, 1+len(posts)+len(categories)+len(authors))
+	urls = append(urls, sitemap.URL{Loc: s.site.URL + "/"})
+	for _, page := range posts {
+		urls = append(urls, sitemap.URL{Loc: s.site.PostURL(page.ID), LastMod: page.LastMod})
+	}
+	for _, page := range categories {
+		urls = append(urls, sitemap.URL{Loc: s.site.CategoryURL(page.ID), LastMod: page.LastMod})
+	}
+	for _, page := range authors {
+		urls = append(urls, sitemap.URL{Loc: s.site.AuthorURL(page.ID), LastMod: page.LastMod})
+	}
+
+	// The home page changes with every post
+	urls[0].LastMod = sitemap.LastMod(urls[1:])
+	return urls, nil
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// TrashPurger periodically hard-deletes posts that have been in the trash longer than the retention period
+type TrashPurger struct {
+	service  TrashService
+	interval time.Duration
+}
+
+func NewTrashPurger(service TrashService, interval time.Duration) *TrashPurger {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	return &TrashPurger{service: service, interval: interval}
+}
+
+// Run purges once immediately and then every interval until ctx is cancelled
+func (p *TrashPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := p.service.PurgeExpired()
+		if err != nil {
+			log.Printf("WARNING: %v", err)
+		} else if purged > 0 {
+			log.Printf("INFO: purged %d posts from the trash", purged)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"crud_api/errors"
+	"crud_api/models"
+	"crud_api/repositories"
+	"time"
+)
+
+type TrashService interface {
+	List(userID uint, offset, limit int) ([]models.Post, int64, error)
+	Restore(id uint, user models.User) (*models.Post, error)
+	Purge(id uint) error
+	PurgeExpired() (int64, error)
+	Retention() time.Duration
+}
+
+type trashService struct {
+	repo      repositories.PostRepository
+	retention time.Duration
+}
+
+// NewTrashService creates a TrashService; trashed posts are kept for retention, or forever when it is zero
+func NewTrashService(repo repositories.PostRepository, retention time.Duration) TrashService {
+	return &trashService{repo: repo, retention: retention}
+}
+
+func (s *trashService) List(userID uint, offset, limit int) ([]models.Post, int64, error) {
+	return s.repo.FindTrashed(userID, offset, limit)
+}
+
+func (s *trashService) Restore(id uint, user models.User) (*models.Post, error) {
+	post, err := s.repo.FindTrashedByID(id)
+	if err != nil {
+		return nil, err
+	}
+	// Any owner may delete the post, so any owner may bring it back
+	if post.RoleOf(user.ID) != models.CollaboratorRoleOwner && user.Role != models.RoleAdmin {
+		return nil, errors.Forbidden("You are not authorized to restore this post", "Tried to restore unauthorized post")
+	}
+
+	// Titles are unique per author among live posts
+	_, err = s.repo.FindDuplicate(post.Title, post.AuthorID)
+	if err == nil {
+		return nil, errors.Conflict("Another post with the same title exists; rename or delete it before restoring", "Tried to restore a post over a duplicate title")
+	}
+	if appErr, ok := err.(*errors.AppErrors); !ok || appErr.Code != 404 {
+		return nil, err
+	}
+
+	if err := s.repo.Restore(post.ID); err != nil {
+		return nil, err
+	}
+	return s.repo.FindByID(post.ID)
+}
+
+func (s *trashService) Purge(id uint) error {
+	return s.repo.Purge(id)
+}
+
+func (s *trashService) PurgeExpired() (int64, error) {
+	if s.retention <= 0 {
+		return 0, nil
+	}
+	return s.repo.PurgeDeletedBefore(time.Now().Add(-s.retention))
+}
+
+func (s *trashService) Retention() time.Duration {
+	return s.retention
+}
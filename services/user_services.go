@@ -4,6 +4,7 @@ import (
 	"crud_api/errors"
 	"crud_api/models"
 	"crud_api/repositories"
+	"fmt"
 	"os"
 	"time"
 
@@ -16,14 +17,24 @@ type UserService interface {
 	Authenticate(email, password string) (*models.User, string, error)
 	GetAllUsers() ([]models.User, error)
 	GetByID(id uint) (*models.User, error)
+	UpdateRole(id uint, role string) (*models.User, error)
+	SetDisabled(id uint, disabled bool, actor models.User) (*models.User, error)
+	Delete(id uint, actor models.User) error
+	// BootstrapAdmins makes the users with an admin email admins and returns how many it promoted.
+	// Only existing accounts are promoted; an admin email registered later is picked up on the
+	// next start.
+	BootstrapAdmins() (int, error)
 }
 
 type userService struct {
-	repo repositories.UserRepository
+	repo        repositories.UserRepository
+	adminEmails []string
 }
 
-func NewUserService(repo repositories.UserRepository) UserService {
-	return &userService{repo}
+// NewUserService creates a UserService; BootstrapAdmins makes the users with one of adminEmails
+// admins, so a new deployment has someone who can grant roles
+func NewUserService(repo repositories.UserRepository, adminEmails []string) UserService {
+	return &userService{repo: repo, adminEmails: adminEmails}
 }
 
 func (s *userService) Register(user *models.User) error {
@@ -59,6 +70,9 @@ func (s *userService) Authenticate(email, password string) (*models.User, string
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
 		return nil, "", errors.Internal("Invalid email or password", "User tried logging in with invalid email and password", err)
 	}
+	if user.Disabled {
+		return nil, "", errors.Forbidden("This account has been disabled", "Disabled user tried logging in")
+	}
 
 	// JWT generation
 	jwtSecret := []byte(os.Getenv("JWT_SECRET"))
@@ -83,3 +97,61 @@ func (s *userService) GetAllUsers() ([]models.User, error) {
 func (s *userService) GetByID(id uint) (*models.User, error) {
 	return s.repo.FindByID(id)
 }
+
+func (s *userService) UpdateRole(id uint, role string) (*models.User, error) {
+	switch role {
+	case models.RoleUser, models.RoleModerator, models.RoleAdmin:
+	default:
+		return nil, errors.BadRequest("Role must be one of user, moderator or admin", "Client sent unknown role '"+role+"'")
+	}
+
+	if _, err := s.repo.FindByID(id); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateRole(id, role); err != nil {
+		return nil, err
+	}
+	return s.repo.FindByID(id)
+}
+
+func (s *userService) SetDisabled(id uint, disabled bool, actor models.User) (*models.User, error) {
+	if id == actor.ID {
+		return nil, errors.BadRequest("You cannot disable your own account", "Admin tried to change their own account status")
+	}
+
+	if _, err := s.repo.FindByID(id); err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetDisabled(id, disabled); err != nil {
+		return nil, err
+	}
+	return s.repo.FindByID(id)
+}
+
+func (s *userService) Delete(id uint, actor models.User) error {
+	if id == actor.ID {
+		return errors.BadRequest("You cannot delete your own account", "Admin tried to delete their own account")
+	}
+	return s.repo.Delete(id)
+}
+
+func (s *userService) BootstrapAdmins() (int, error) {
+	promoted := 0
+	for _, email := range s.adminEmails {
+		user, err := s.repo.FindByEmail(email)
+		if err != nil {
+			if appErr, ok := err.(*errors.AppErrors); ok && appErr.Code == 404 {
+				continue
+			}
+			return promoted, fmt.Errorf("looking up admin %s: %w", email, err)
+		}
+		if user.Role == models.RoleAdmin {
+			continue
+		}
+		if err := s.repo.UpdateRole(user.ID, models.RoleAdmin); err != nil {
+			return promoted, fmt.Errorf("promoting admin %s: %w", email, err)
+		}
+		promoted++
+	}
+	return promoted, nil
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"regexp"
+	"time"
+
+	"crud_api/models"
+	"crud_api/repositories"
+)
+
+// botPattern matches user agents of crawlers, link previewers and scripted clients
+var botPattern = regexp.MustCompile(`(?i)(bot|crawl|spider|slurp|curl|wget|python-requests|go-http-client|httpclient|headless|lighthouse|preview|facebookexternalhit|monitor)`)
+
+type viewEvent struct {
+	postID      uint
+	day         time.Time
+	visitorHash string
+}
+
+// ViewRecorder collects post views in memory and writes them to the daily counters in
+// batches from a background goroutine, so reading a post never waits on a write
+type ViewRecorder struct {
+	repo          repositories.ViewRepository
+	salt          string
+	events        chan viewEvent
+	flushInterval time.Duration
+	batchSize     int
+}
+
+// NewViewRecorder creates a recorder; salt keeps visitor hashes from being reversed by
+// brute-forcing IP addresses. Without one a random salt is used, which counts visitors again
+// after every restart.
+func NewViewRecorder(repo repositories.ViewRepository, salt string, flushInterval time.Duration) *ViewRecorder {
+	if salt == "" {
+		log.Printf("WARNING: VIEW_HASH_SALT is not set; using a random salt, so visitors are counted again after each restart")
+		random := make([]byte, 32)
+		if _, err := rand.Read(random); err != nil {
+			panic("failed to generate view hash salt: " + err.Error())
+		}
+		salt = hex.EncodeToString(random)
+	}
+	return &ViewRecorder{
+		repo:          repo,
+		salt:          salt,
+		events:        make(chan viewEvent, 10000),
+		flushInterval: flushInterval,
+		batchSize:     500,
+	}
+}
+
+// Record queues a view. Bots are ignored, and views are dropped (not blocked on) when the queue is full.
+func (r *ViewRecorder) Record(postID uint, ip, userAgent string) {
+	if userAgent == "" || botPattern.MatchString(userAgent) {
+		return
+	}
+
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	sum := sha256.Sum256([]byte(r.salt + "|" + day.Format("2006-01-02") + "|" + ip + "|" + userAgent))
+
+	select {
+	case r.events <- viewEvent{postID: postID, day: day, visitorHash: hex.EncodeToString(sum[:])}:
+	default:
+		log.Printf("WARNING: view queue full, dropping view of post %d", postID)
+	}
+}
+
+// Run aggregates queued views until ctx is cancelled, then flushes what is left
+func (r *ViewRecorder) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.flushInterval)
+	defer ticker.Stop()
+
+	pending := make(map[viewEvent]struct{})
+	lastPrune := time.Time{}
+
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		visits := make([]models.PostViewVisitor, 0, len(pending))
+		for event := range pending {
+			visits = append(visits, models.PostViewVisitor{PostID: event.postID, Day: event.day, VisitorHash: event.visitorHash})
+			if len(visits) == r.batchSize {
+				r.write(visits)
+				visits = visits[:0]
+			}
+		}
+		r.write(visits)
+		pending = make(map[viewEvent]struct{})
+
+		// Visitor hashes are only needed to deduplicate views of the current day
+		if time.Since(lastPrune) > time.Hour {
+			if err := r.repo.PruneVisitors(time.Now().UTC().AddDate(0, 0, -2)); err != nil {
+				log.Printf("WARNING: %v", err)
+			}
+			lastPrune = time.Now()
+		}
+	}
+
+	for {
+		select {
+		case event := <-r.events:
+			pending[event] = struct{}{}
+		case <-ticker.C:
+			flush()
+		case <-ctx.Done():
+			for {
+				select {
+				case event := <-r.events:
+					pending[event] = struct{}{}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (r *ViewRecorder) write(visits []models.PostViewVisitor) {
+	if err := r.repo.RecordVisits(visits); err != nil {
+		log.Printf("SEVERE: %v", err)
+	}
+}
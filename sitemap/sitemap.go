@@ -0,0 +1,79 @@
+// Package sitemap renders sitemaps and sitemap indexes following the sitemaps.org protocol
+package sitemap
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// MaxURLs is the most URLs the protocol allows in a single sitemap
+const MaxURLs = 50000
+
+// ContentType of rendered sitemaps and indexes
+const ContentType = "application/xml; charset=utf-8"
+
+const namespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// URL is one page listed in a sitemap; LastMod is left out when zero
+type URL struct {
+	Loc     string
+	LastMod time.Time
+}
+
+type urlSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	URLs    []urlElement `xml:"url"`
+}
+
+type urlElement struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name     `xml:"sitemapindex"`
+	XMLNS    string       `xml:"xmlns,attr"`
+	Sitemaps []urlElement `xml:"sitemap"`
+}
+
+// Render returns a sitemap listing the URLs; callers keep it at or below MaxURLs
+func Render(urls []URL) ([]byte, error) {
+	return marshal(urlSet{XMLNS: namespace, URLs: elements(urls)})
+}
+
+// RenderIndex returns a sitemap index pointing at the given sitemaps
+func RenderIndex(sitemaps []URL) ([]byte, error) {
+	return marshal(sitemapIndex{XMLNS: namespace, Sitemaps: elements(sitemaps)})
+}
+
+// LastMod returns the latest modification time of the URLs
+func LastMod(urls []URL) time.Time {
+	var latest time.Time
+	for _, url := range urls {
+		if url.LastMod.After(latest) {
+			latest = url.LastMod
+		}
+	}
+	return latest
+}
+
+func elements(urls []URL) []urlElement {
+	result := make([]urlElement, 0, len(urls))
+	for _, url := range urls {
+		element := urlElement{Loc: url.Loc}
+		if !url.LastMod.IsZero() {
+			element.LastMod = url.LastMod.UTC().Format(time.RFC3339)
+		}
+		result = append(result, element)
+	}
+	return result
+}
+
+func marshal(document interface{}) ([]byte, error) {
+	body, err := xml.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
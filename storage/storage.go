@@ -0,0 +1,45 @@
+// Package storage keeps uploaded files in a local directory or an S3-compatible bucket
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrNotFound is returned when an object doesn't exist
+var ErrNotFound = errors.New("storage: object not found")
+
+// Storage stores objects under slash separated keys such as "media/1/ab12.png"
+type Storage interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object; deleting a missing object is not an error
+	Delete(ctx context.Context, key string) error
+	// URL returns the public address the object is served at
+	URL(key string) string
+}
+
+// Config selects and configures a Storage implementation
+type Config struct {
+	Backend   string // "local" or "s3"
+	PublicURL string // base URL objects are served from; for S3 it defaults to the bucket URL
+
+	Dir string // local: directory the objects are written to
+
+	S3 S3Config
+}
+
+// New returns the configured storage
+func New(cfg Config) (Storage, error) {
+	switch cfg.Backend {
+	case "", "local":
+		return NewLocalStorage(cfg.Dir, cfg.PublicURL)
+	case "s3":
+		s3 := cfg.S3
+		s3.PublicURL = cfg.PublicURL
+		return NewS3Storage(s3)
+	}
+	return nil, fmt.Errorf("unknown storage backend %q", cfg.Backend)
+}